@@ -0,0 +1,48 @@
+package chroma
+
+import (
+	"errors"
+	"io"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/chuabingquan/snippets"
+)
+
+// Highlighter implements the snippets.Highlighter interface to render code as HTML with
+// inline styles, so that no stylesheet has to accompany it
+type Highlighter struct {
+	TabWidth int
+}
+
+// Highlight tokenises code as the given language and writes it to w as HTML styled with
+// the theme in opts. Code in a language without a lexer is rendered as plain text
+func (h Highlighter) Highlight(w io.Writer, code string, language string, opts snippets.HighlightOptions) error {
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return errors.New("Failed to tokenise code: " + err.Error())
+	}
+
+	formatter := html.New(
+		html.WithLineNumbers(opts.LineNumbers),
+		html.LineNumbersInTable(true),
+		html.TabWidth(h.TabWidth),
+	)
+	err = formatter.Format(w, styles.Get(opts.Theme), iterator)
+	if err != nil {
+		return errors.New("Failed to format code: " + err.Error())
+	}
+	return nil
+}
+
+// HasTheme checks if a theme with the given name can be used to render code
+func (h Highlighter) HasTheme(theme string) bool {
+	_, ok := styles.Registry[theme]
+	return ok
+}
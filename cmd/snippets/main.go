@@ -8,7 +8,9 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/chuabingquan/snippets"
 	"github.com/chuabingquan/snippets/bcrypt"
+	"github.com/chuabingquan/snippets/chroma"
 	"github.com/chuabingquan/snippets/http"
 	"github.com/chuabingquan/snippets/http/jwt"
 	"github.com/chuabingquan/snippets/postgres"
@@ -19,6 +21,7 @@ import (
 func main() {
 	loadEnvironmentVariables()
 	config := getConfig()
+	reaperInterval := time.Duration(toPositiveInt("REAPER_INTERVAL", config["REAPER_INTERVAL"])) * time.Minute
 
 	dbURL := postgres.DBUrl{
 		Protocol: config["DB_PROTOCOL"],
@@ -36,25 +39,41 @@ func main() {
 	}
 	defer db.Close()
 
-	jwtAuthenticator := jwt.Authenticator{
-		SigningKey: []byte(config["AUTH_SECRET"]),
-		ExpiryTime: time.Duration(toInt(config["AUTH_EXPIRY"])) * time.Minute,
-	}
 	hu := bcrypt.Utilities{HashCost: toInt(config["HASH_COST"])}
+	hl := chroma.Highlighter{TabWidth: 4}
 
 	us := postgres.UserService{DB: db, HashUtilities: hu}
+	jwtAuthenticator := jwt.Authenticator{
+		SigningKey:  []byte(config["AUTH_SECRET"]),
+		ExpiryTime:  time.Duration(toInt(config["AUTH_EXPIRY"])) * time.Minute,
+		UserService: us,
+	}
 	ss := postgres.SnippetService{DB: db}
+	ors := postgres.OrganizationService{DB: db}
+	cs := postgres.CollectionService{DB: db}
 	as := postgres.AuthenticationService{DB: db, HashUtilities: hu}
 
-	userHandler := http.NewUserHandler(us, jwtAuthenticator)
-	snippetHandler := http.NewSnippetHandler(ss, jwtAuthenticator)
+	userHandler := http.NewUserHandler(us, ors, jwtAuthenticator)
+	snippetHandler := http.NewSnippetHandler(ss, us, ors, jwtAuthenticator, hl, int64(toInt(config["MAX_SNIPPET_SIZE"])),
+		config["BASE_URL"])
+	orgHandler := http.NewOrgHandler(ors, us, jwtAuthenticator)
+	collectionHandler := http.NewCollectionHandler(cs, ss, jwtAuthenticator)
 	authHandler := http.NewAuthHandler(as, us, jwtAuthenticator)
 
 	handler := http.Handler{
-		UserHandler:    userHandler,
-		SnippetHandler: snippetHandler,
-		AuthHandler:    authHandler,
+		UserHandler:       userHandler,
+		SnippetHandler:    snippetHandler,
+		OrgHandler:        orgHandler,
+		CollectionHandler: collectionHandler,
+		AuthHandler:       authHandler,
 	}
+	if os.Getenv("ENABLE_GIST_API") == "true" {
+		handler.GistHandler = http.NewGistHandler(ss, us, jwtAuthenticator, int64(toInt(config["MAX_SNIPPET_SIZE"])),
+			config["BASE_URL"])
+	}
+
+	go reapExpiredSnippets(ss, reaperInterval)
+	go purgeTrash(ss, us, ors, reaperInterval, time.Duration(toInt(config["TRASH_RETENTION"]))*24*time.Hour)
 
 	server := http.Server{Handler: &handler, Addr: ":" + config["PORT"]}
 	err = server.Open()
@@ -71,6 +90,56 @@ func main() {
 	fmt.Println("Got signal:", s)
 }
 
+// reapExpiredSnippets purges the snippets that have expired every interval for as long as
+// the server runs
+func reapExpiredSnippets(ss snippets.SnippetService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := ss.PurgeExpiredSnippets()
+		if err != nil {
+			log.Println("Failed to purge expired snippets:", err.Error())
+			continue
+		}
+		if purged > 0 {
+			log.Println("Purged", purged, "expired snippets")
+		}
+	}
+}
+
+// purgeTrash purges the snippets, users and organizations that have been deleted for longer
+// than retention every interval for as long as the server runs
+func purgeTrash(ss snippets.SnippetService, us snippets.UserService, ors snippets.OrganizationService,
+	interval time.Duration, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		before := time.Now().Add(-retention)
+		purged, err := ss.PurgeDeletedSnippets(before)
+		if err != nil {
+			log.Println("Failed to purge deleted snippets:", err.Error())
+		} else if purged > 0 {
+			log.Println("Purged", purged, "deleted snippets")
+		}
+
+		purged, err = us.PurgeDeletedUsers(before)
+		if err != nil {
+			log.Println("Failed to purge deleted users:", err.Error())
+		} else if purged > 0 {
+			log.Println("Purged", purged, "deleted users")
+		}
+
+		purged, err = ors.PurgeDeletedOrganizations(before)
+		if err != nil {
+			log.Println("Failed to purge deleted organizations:", err.Error())
+		} else if purged > 0 {
+			log.Println("Purged", purged, "deleted organizations")
+		}
+	}
+}
+
 func toInt(str string) int {
 	val, err := strconv.Atoi(str)
 	if err != nil {
@@ -79,6 +148,16 @@ func toInt(str string) int {
 	return val
 }
 
+// toPositiveInt parses the value of the environment variable with the given name, exiting
+// should it not be a positive integer
+func toPositiveInt(name string, str string) int {
+	val, err := strconv.Atoi(str)
+	if err != nil || val <= 0 {
+		log.Fatal(name + " environment variable has to be a positive integer but is \"" + str + "\"")
+	}
+	return val
+}
+
 func loadEnvironmentVariables() {
 	err := godotenv.Load()
 	if err != nil {
@@ -89,7 +168,8 @@ func loadEnvironmentVariables() {
 func getConfig() map[string]string {
 	config := make(map[string]string)
 	envNames := []string{"DB_PROTOCOL", "DB_USER", "DB_PASSWORD", "DB_HOST", "DB_PORT", "DB_NAME", "DB_SSLMODE",
-		"PORT", "HASH_COST", "AUTH_SECRET", "AUTH_EXPIRY"}
+		"PORT", "HASH_COST", "AUTH_SECRET", "AUTH_EXPIRY", "MAX_SNIPPET_SIZE", "BASE_URL", "REAPER_INTERVAL",
+		"TRASH_RETENTION"}
 	for _, name := range envNames {
 		val, ok := os.LookupEnv(name)
 		if !ok {
@@ -0,0 +1,320 @@
+package diff
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/chuabingquan/snippets"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change by default
+const DefaultContext = 3
+
+// maxEditDistance bounds the work done on a pair of texts. Texts that differ by more edits
+// than this are treated as entirely replaced. The trace kept to recover the edits grows with
+// the square of the edit distance, which is about 8MB at this bound
+const maxEditDistance = 1024
+
+// Op describes how a line changed between two texts
+type Op string
+
+// A line is either kept, inserted into the newer text or deleted from the older one
+const (
+	OpContext Op = "context"
+	OpAdd     Op = "add"
+	OpDelete  Op = "delete"
+)
+
+// Line represents a single line of a hunk
+type Line struct {
+	Op        Op     `json:"op"`
+	Text      string `json:"text"`
+	NoNewline bool   `json:"noNewlineAtEnd,omitempty"`
+}
+
+// Hunk represents a contiguous region of changes along with its surrounding context
+type Hunk struct {
+	OldStart int    `json:"oldStart"`
+	OldLines int    `json:"oldLines"`
+	NewStart int    `json:"newStart"`
+	NewLines int    `json:"newLines"`
+	Lines    []Line `json:"lines"`
+}
+
+// File represents the changes made to a single file. A file that was added has no
+// OldFilename while a file that was removed has no NewFilename
+type File struct {
+	OldFilename string `json:"oldFilename,omitempty"`
+	NewFilename string `json:"newFilename,omitempty"`
+	Hunks       []Hunk `json:"hunks"`
+}
+
+// Revisions compares the files of two revisions by filename and returns the changes made
+// to every file that differs between them
+func Revisions(from snippets.Revision, to snippets.Revision, context int) []File {
+	files := []File{}
+	newFiles := make(map[string]string)
+	for _, f := range to.Files {
+		newFiles[f.Filename] = f.Content
+	}
+	oldFiles := make(map[string]string)
+	for _, f := range from.Files {
+		oldFiles[f.Filename] = f.Content
+		newContent, ok := newFiles[f.Filename]
+		if !ok {
+			files = append(files, File{OldFilename: f.Filename, Hunks: Hunks(f.Content, "", context)})
+		} else if newContent != f.Content {
+			files = append(files, File{OldFilename: f.Filename, NewFilename: f.Filename,
+				Hunks: Hunks(f.Content, newContent, context)})
+		}
+	}
+	for _, f := range to.Files {
+		if _, ok := oldFiles[f.Filename]; !ok {
+			files = append(files, File{NewFilename: f.Filename, Hunks: Hunks("", f.Content, context)})
+		}
+	}
+	return files
+}
+
+// Hunks computes the line based differences between a and b, grouped into hunks with
+// the given number of context lines
+func Hunks(a string, b string, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+	script := editScript(splitLines(a), splitLines(b))
+
+	hunks := []Hunk{}
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(script); {
+		if script[i].Op == OpContext {
+			oldLine, newLine = oldLine+1, newLine+1
+			i++
+			continue
+		}
+
+		// extend the hunk over every change that is within 2*context lines of the last one
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end, unchanged := i, 0
+		for j := i; j < len(script) && unchanged <= 2*context; j++ {
+			if script[j].Op == OpContext {
+				unchanged++
+			} else {
+				unchanged, end = 0, j
+			}
+		}
+		end += context
+		if end >= len(script) {
+			end = len(script) - 1
+		}
+
+		hunk := Hunk{OldStart: oldLine - (i - start), NewStart: newLine - (i - start)}
+		for _, line := range script[start : end+1] {
+			if line.Op != OpAdd {
+				hunk.OldLines++
+			}
+			if line.Op != OpDelete {
+				hunk.NewLines++
+			}
+			hunk.Lines = append(hunk.Lines, line)
+		}
+		// an empty range starts at the line before it by convention
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		hunks = append(hunks, hunk)
+
+		for _, line := range script[i : end+1] {
+			if line.Op != OpAdd {
+				oldLine++
+			}
+			if line.Op != OpDelete {
+				newLine++
+			}
+		}
+		i = end + 1
+	}
+	return hunks
+}
+
+// WriteUnified writes the given file changes to w in the unified diff format
+func WriteUnified(w io.Writer, files []File) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range files {
+		oldName, newName := "/dev/null", "/dev/null"
+		if f.OldFilename != "" {
+			oldName = "a/" + f.OldFilename
+		}
+		if f.NewFilename != "" {
+			newName = "b/" + f.NewFilename
+		}
+		bw.WriteString("--- " + oldName + "\n+++ " + newName + "\n")
+
+		for _, h := range f.Hunks {
+			bw.WriteString("@@ -" + formatRange(h.OldStart, h.OldLines) + " +" + formatRange(h.NewStart, h.NewLines) + " @@\n")
+			for _, line := range h.Lines {
+				switch line.Op {
+				case OpAdd:
+					bw.WriteString("+")
+				case OpDelete:
+					bw.WriteString("-")
+				default:
+					bw.WriteString(" ")
+				}
+				bw.WriteString(line.Text + "\n")
+				if line.NoNewline {
+					bw.WriteString("\\ No newline at end of file\n")
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// formatRange formats the start and length of a hunk's range, omitting a length of 1
+func formatRange(start int, length int) string {
+	if length == 1 {
+		return strconv.Itoa(start)
+	}
+	return strconv.Itoa(start) + "," + strconv.Itoa(length)
+}
+
+// splitLines splits s into lines that keep their trailing newline, so that a missing
+// newline at the end of a text is treated as a change to its last line
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// newLine converts a line as split by splitLines into a Line with the given op
+func newLine(op Op, s string) Line {
+	return Line{Op: op, Text: strings.TrimSuffix(s, "\n"), NoNewline: !strings.HasSuffix(s, "\n")}
+}
+
+// editScript returns the shortest sequence of lines kept, deleted and added that turns a
+// into b, computed with Myers' difference algorithm
+func editScript(a []string, b []string) []Line {
+	// lines shared at the start and end of both texts are never part of a change
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	script := make([]Line, 0, len(a)+len(b))
+	for _, s := range a[:prefix] {
+		script = append(script, newLine(OpContext, s))
+	}
+	script = append(script, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, s := range a[len(a)-suffix:] {
+		script = append(script, newLine(OpContext, s))
+	}
+	return script
+}
+
+// myers computes the edit script between a and b. The furthest reaching x of the diagonals
+// -d-1 to d+1 is kept for each edit distance d so that the path can be traced back
+func myers(a []string, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	max := n + m
+	if max > maxEditDistance {
+		max = maxEditDistance
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// backtrack walks the trace recorded by myers from the end of both texts to their start
+// and returns the edit script in order
+func backtrack(a []string, b []string, trace [][]int) []Line {
+	var reversed []Line
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		// trace[d] starts at diagonal -d-1
+		v, offset := trace[d], d+1
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, newLine(OpContext, a[x-1]))
+			x, y = x-1, y-1
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, newLine(OpAdd, b[y-1]))
+			} else {
+				reversed = append(reversed, newLine(OpDelete, a[x-1]))
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	script := make([]Line, len(reversed))
+	for i, line := range reversed {
+		script[len(reversed)-1-i] = line
+	}
+	return script
+}
+
+// replaceAll returns an edit script that deletes every line of a before adding every line of b
+func replaceAll(a []string, b []string) []Line {
+	script := make([]Line, 0, len(a)+len(b))
+	for _, s := range a {
+		script = append(script, newLine(OpDelete, s))
+	}
+	for _, s := range b {
+		script = append(script, newLine(OpAdd, s))
+	}
+	return script
+}
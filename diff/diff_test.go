@@ -0,0 +1,132 @@
+package diff
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{
+			name: "empty inputs",
+		},
+		{
+			name:    "identical inputs",
+			a:       "a\nb\nc\n",
+			b:       "a\nb\nc\n",
+			context: 3,
+		},
+		{
+			name:    "insert only",
+			b:       "a\nb\n",
+			context: 3,
+			want:    "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:    "delete only",
+			a:       "a\nb\nc\n",
+			b:       "a\nc\n",
+			context: 1,
+			want:    "@@ -1,3 +1,2 @@\n a\n-b\n c\n",
+		},
+		{
+			name:    "delete everything",
+			a:       "a\nb\n",
+			context: 3,
+			want:    "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:    "changes far apart stay in separate hunks",
+			a:       "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:       "1\ntwo\n3\n4\n5\n6\n7\neight\n9\n10\n",
+			context: 2,
+			want: "@@ -1,4 +1,4 @@\n 1\n-2\n+two\n 3\n 4\n" +
+				"@@ -6,5 +6,5 @@\n 6\n 7\n-8\n+eight\n 9\n 10\n",
+		},
+		{
+			name:    "changes within twice the context are merged into one hunk",
+			a:       "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:       "1\ntwo\n3\n4\n5\n6\nseven\n8\n9\n10\n",
+			context: 2,
+			want:    "@@ -1,9 +1,9 @@\n 1\n-2\n+two\n 3\n 4\n 5\n 6\n-7\n+seven\n 8\n 9\n",
+		},
+		{
+			name:    "no context",
+			a:       "a\nb\nc\n",
+			b:       "a\nB\nc\n",
+			context: 0,
+			want:    "@@ -2 +2 @@\n-b\n+B\n",
+		},
+		{
+			name:    "missing final newline added",
+			a:       "a\nb",
+			b:       "a\nb\n",
+			context: 3,
+			want:    "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name:    "missing final newline kept",
+			a:       "a\nb",
+			b:       "A\nb",
+			context: 3,
+			want:    "@@ -1,2 +1,2 @@\n-a\n+A\n b\n\\ No newline at end of file\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hunks := Hunks(test.a, test.b, test.context)
+			if test.want == "" && len(hunks) != 0 {
+				t.Fatalf("expected no hunks, got %+v", hunks)
+			}
+
+			var buf bytes.Buffer
+			if err := WriteUnified(&buf, []File{{OldFilename: "f", NewFilename: "f", Hunks: hunks}}); err != nil {
+				t.Fatal(err)
+			}
+			want := "--- a/f\n+++ b/f\n" + test.want
+			if got := buf.String(); got != want {
+				t.Errorf("unexpected diff\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestHunksBeyondMaxEditDistance(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < maxEditDistance; i++ {
+		a.WriteString("a" + strconv.Itoa(i) + "\n")
+		b.WriteString("b" + strconv.Itoa(i) + "\n")
+	}
+
+	hunks := Hunks(a.String(), b.String(), 3)
+	if len(hunks) != 1 {
+		t.Fatalf("expected a single hunk, got %d", len(hunks))
+	}
+	h := hunks[0]
+	if h.OldStart != 1 || h.OldLines != maxEditDistance || h.NewStart != 1 || h.NewLines != maxEditDistance {
+		t.Errorf("expected every line to be replaced, got -%d,%d +%d,%d", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	}
+}
+
+func TestWriteUnifiedAddedAndRemovedFiles(t *testing.T) {
+	files := []File{
+		{NewFilename: "new.go", Hunks: Hunks("", "x\n", 3)},
+		{OldFilename: "old.go", Hunks: Hunks("y\n", "", 3)},
+	}
+	var buf bytes.Buffer
+	if err := WriteUnified(&buf, files); err != nil {
+		t.Fatal(err)
+	}
+	want := "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+x\n" +
+		"--- a/old.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-y\n"
+	if got := buf.String(); got != want {
+		t.Errorf("unexpected diff\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
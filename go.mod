@@ -1,9 +1,9 @@
 module github.com/chuabingquan/snippets
 
-go 1.12
+go 1.19
 
 require (
-	github.com/asaskevich/govalidator v0.0.0-20190424111038-f61b66f89f4a // indirect
+	github.com/alecthomas/chroma v0.10.0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/go-ozzo/ozzo-validation v3.5.0+incompatible
 	github.com/google/uuid v1.1.1
@@ -11,6 +11,10 @@ require (
 	github.com/jmoiron/sqlx v1.2.0
 	github.com/joho/godotenv v1.3.0
 	github.com/lib/pq v1.0.0
-	github.com/stretchr/testify v1.3.0 // indirect
 	golang.org/x/crypto v0.0.0-20190621222207-cc06ce4a13d4
 )
+
+require (
+	github.com/asaskevich/govalidator v0.0.0-20190424111038-f61b66f89f4a // indirect
+	github.com/dlclark/regexp2 v1.4.0 // indirect
+)
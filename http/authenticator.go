@@ -20,11 +20,16 @@ func verifyRoute(a Authenticator) Adapter {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ok, err := a.Authenticate(r)
-			if err != nil {
+			if err == snippets.ErrInvalidTokenFormat {
 				createResponse(w, http.StatusBadRequest, defaultResponse{
 					"Invalid token format supplied"})
 				return
 			}
+			if err != nil {
+				createResponse(w, http.StatusInternalServerError, defaultResponse{
+					"An unexpected error occurred when authenticating"})
+				return
+			}
 			if !ok {
 				createResponse(w, http.StatusUnauthorized, defaultResponse{
 					"Invalid token supplied"})
@@ -35,3 +40,18 @@ func verifyRoute(a Authenticator) Adapter {
 		})
 	}
 }
+
+// identifyRoute is a middleware that permits anonymous entry to a route while still
+// rejecting requests that supply an invalid token, so that a route may serve both
+// authenticated users and the public
+func identifyRoute(a Authenticator) Adapter {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+			verifyRoute(a)(h).ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// collaboratorRequest represents the request body granting a user a role on a snippet
+type collaboratorRequest struct {
+	Role snippets.Role `json:"role"`
+}
+
+// handleGetCollaborators
+func (sh SnippetHandler) handleGetCollaborators(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving collaborators"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving collaborators"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	collaborators, err := sh.SnippetService.Collaborators(snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving collaborators"})
+		return
+	}
+	createResponse(w, http.StatusOK, collaborators)
+}
+
+// handleSetCollaborator
+func (sh SnippetHandler) handleSetCollaborator(w http.ResponseWriter, r *http.Request) {
+	snippetID, userID := mux.Vars(r)["snippetID"], mux.Vars(r)["userID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when setting collaborator"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when setting collaborator"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !snippet.Role.CanManage() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owner of this snippet is permitted to do this"})
+		return
+	}
+
+	var req collaboratorRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&req)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"JSON could not be decoded, invalid request format supplied"})
+		return
+	}
+
+	collaborator := snippets.Collaborator{SnippetID: snippetID, UserID: userID, Role: req.Role}
+	err = collaborator.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if userID == snippet.Owner {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, the owner of a snippet cannot be made a collaborator of it"})
+		return
+	}
+
+	user, err := sh.UserService.User(userID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when setting collaborator"})
+		return
+	}
+	if user == (snippets.User{}) {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested user is not found"})
+		return
+	}
+
+	err = sh.SnippetService.SetCollaborator(collaborator)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when setting collaborator"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Collaborator is successfully set"})
+}
+
+// handleRemoveCollaborator
+func (sh SnippetHandler) handleRemoveCollaborator(w http.ResponseWriter, r *http.Request) {
+	snippetID, userID := mux.Vars(r)["snippetID"], mux.Vars(r)["userID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when removing collaborator"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when removing collaborator"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	// collaborators are free to leave a snippet on their own
+	if !snippet.Role.CanManage() && userID != userInfo.UserID {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owner of this snippet is permitted to do this"})
+		return
+	}
+
+	err = sh.SnippetService.RemoveCollaborator(snippetID, userID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when removing collaborator"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Collaborator is successfully removed"})
+}
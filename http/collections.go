@@ -0,0 +1,403 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// CollectionHandler is a sub-router that handles requests related to operations on Collections
+type CollectionHandler struct {
+	*mux.Router
+	CollectionService snippets.CollectionService
+	SnippetService    snippets.SnippetService
+	Authenticator     Authenticator
+}
+
+// NewCollectionHandler constructs a new CollectionHandler given a CollectionService
+// implementation and a SnippetService implementation to check collected snippets with
+func NewCollectionHandler(cs snippets.CollectionService, ss snippets.SnippetService, auth Authenticator) *CollectionHandler {
+	h := &CollectionHandler{
+		Router:            mux.NewRouter(),
+		CollectionService: cs,
+		SnippetService:    ss,
+		Authenticator:     auth,
+	}
+
+	verifyUser := verifyRoute(auth)
+	identifyUser := identifyRoute(auth)
+
+	h.Handle("/api/v0/collections", Adapt(http.HandlerFunc(h.handleGetCollections), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/collections/public", Adapt(http.HandlerFunc(h.handleGetPublicCollections))).Methods("GET")
+	h.Handle("/api/v0/collections/shared/{shareToken}", Adapt(http.HandlerFunc(h.handleGetSharedCollection))).Methods("GET")
+	h.Handle("/api/v0/collections/shared/{shareToken}/snippets", Adapt(http.HandlerFunc(h.handleGetSharedCollectionSnippets))).Methods("GET")
+	h.Handle("/api/v0/collections/{collectionID}", Adapt(http.HandlerFunc(h.handleGetCollectionByID), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/collections", Adapt(http.HandlerFunc(h.handleCreateCollection), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/collections/{collectionID}", Adapt(http.HandlerFunc(h.handlePatchCollection), verifyUser)).Methods("PATCH")
+	h.Handle("/api/v0/collections/{collectionID}", Adapt(http.HandlerFunc(h.handleDeleteCollection), verifyUser)).Methods("DELETE")
+	h.Handle("/api/v0/collections/{collectionID}/snippets", Adapt(http.HandlerFunc(h.handleGetCollectionSnippets), identifyUser)).Methods("GET")
+
+	h.Handle("/api/v0/collections/{collectionID}/share", Adapt(http.HandlerFunc(h.handleGetCollectionShareToken), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/collections/{collectionID}/share", Adapt(http.HandlerFunc(h.handleGenerateCollectionShareToken), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/collections/{collectionID}/share", Adapt(http.HandlerFunc(h.handleRevokeCollectionShareToken), verifyUser)).Methods("DELETE")
+
+	return h
+}
+
+// handleGetCollections
+func (ch CollectionHandler) handleGetCollections(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := ch.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving collections"})
+		return
+	}
+
+	collections, err := ch.CollectionService.Collections(userInfo.UserID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving collections"})
+		return
+	}
+	createResponse(w, http.StatusOK, collections)
+}
+
+// handleGetPublicCollections
+func (ch CollectionHandler) handleGetPublicCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := ch.CollectionService.PublicCollections()
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving collections"})
+		return
+	}
+	createResponse(w, http.StatusOK, collections)
+}
+
+// handleGetCollectionByID
+func (ch CollectionHandler) handleGetCollectionByID(w http.ResponseWriter, r *http.Request) {
+	collection, ok := ch.viewableCollection(w, r)
+	if !ok {
+		return
+	}
+	createResponse(w, http.StatusOK, collection)
+}
+
+// handleGetCollectionSnippets
+func (ch CollectionHandler) handleGetCollectionSnippets(w http.ResponseWriter, r *http.Request) {
+	collection, ok := ch.viewableCollection(w, r)
+	if !ok {
+		return
+	}
+	ch.respondCollectionSnippets(w, collection)
+}
+
+// handleGetSharedCollection
+func (ch CollectionHandler) handleGetSharedCollection(w http.ResponseWriter, r *http.Request) {
+	collection, ok := ch.sharedCollection(w, r)
+	if !ok {
+		return
+	}
+	createResponse(w, http.StatusOK, collection)
+}
+
+// handleGetSharedCollectionSnippets
+func (ch CollectionHandler) handleGetSharedCollectionSnippets(w http.ResponseWriter, r *http.Request) {
+	collection, ok := ch.sharedCollection(w, r)
+	if !ok {
+		return
+	}
+	ch.respondCollectionSnippets(w, collection)
+}
+
+// handleCreateCollection
+func (ch CollectionHandler) handleCreateCollection(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := ch.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating collection"})
+		return
+	}
+
+	var newCollection snippets.Collection
+	err = json.NewDecoder(r.Body).Decode(&newCollection)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Invalid request body"})
+		return
+	}
+
+	newCollection.Owner = userInfo.UserID
+	if newCollection.Visibility == "" {
+		newCollection.Visibility = snippets.VisibilityPrivate
+	}
+
+	err = newCollection.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !ch.areCollectable(w, userInfo.UserID, newCollection.SnippetIDs, nil) {
+		return
+	}
+
+	collectionID, err := ch.CollectionService.CreateCollection(newCollection)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating collection"})
+		return
+	}
+
+	collection, err := ch.CollectionService.Collection(userInfo.UserID, collectionID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting created collection"})
+		return
+	}
+	createResponse(w, http.StatusCreated, collection)
+}
+
+// handlePatchCollection
+func (ch CollectionHandler) handlePatchCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID := mux.Vars(r)["collectionID"]
+	userInfo, err := ch.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating collection"})
+		return
+	}
+
+	collectionToUpdate, err := ch.CollectionService.Collection(userInfo.UserID, collectionID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested collection"})
+		return
+	}
+	if collectionToUpdate.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, collection to update is not found"})
+		return
+	}
+
+	// decoding may reuse the backing array of the snippet IDs, so they are copied beforehand
+	collected := append([]string(nil), collectionToUpdate.SnippetIDs...)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&collectionToUpdate)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"JSON could not be decoded, invalid request format supplied"})
+		return
+	}
+
+	if collectionToUpdate.ID != collectionID {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"JSON could not be decoded, invalid request format supplied"})
+		return
+	}
+
+	err = collectionToUpdate.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !ch.areCollectable(w, userInfo.UserID, collectionToUpdate.SnippetIDs, collected) {
+		return
+	}
+
+	err = ch.CollectionService.UpdateCollection(collectionToUpdate)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating collection"})
+		return
+	}
+
+	createResponse(w, http.StatusOK, defaultResponse{"Collection is successfully updated"})
+}
+
+// handleDeleteCollection
+func (ch CollectionHandler) handleDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID := mux.Vars(r)["collectionID"]
+	userInfo, err := ch.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting collection"})
+		return
+	}
+
+	collectionToDelete, err := ch.CollectionService.Collection(userInfo.UserID, collectionID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested collection"})
+		return
+	}
+	if collectionToDelete.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, collection to delete is not found"})
+		return
+	}
+
+	err = ch.CollectionService.DeleteCollection(userInfo.UserID, collectionID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting collection"})
+		return
+	}
+
+	createResponse(w, http.StatusOK, defaultResponse{"Collection is successfully deleted"})
+}
+
+// handleGetCollectionShareToken
+func (ch CollectionHandler) handleGetCollectionShareToken(w http.ResponseWriter, r *http.Request) {
+	collection, ok := ch.ownedCollection(w, r, "getting share token")
+	if !ok {
+		return
+	}
+	if collection.ShareToken == nil {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested collection has no share token"})
+		return
+	}
+	createResponse(w, http.StatusOK, shareTokenResponse{*collection.ShareToken})
+}
+
+// handleGenerateCollectionShareToken
+func (ch CollectionHandler) handleGenerateCollectionShareToken(w http.ResponseWriter, r *http.Request) {
+	collection, ok := ch.ownedCollection(w, r, "generating share token")
+	if !ok {
+		return
+	}
+
+	shareToken, err := ch.CollectionService.GenerateCollectionShareToken(collection.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when generating share token"})
+		return
+	}
+	createResponse(w, http.StatusCreated, shareTokenResponse{shareToken})
+}
+
+// handleRevokeCollectionShareToken
+func (ch CollectionHandler) handleRevokeCollectionShareToken(w http.ResponseWriter, r *http.Request) {
+	collection, ok := ch.ownedCollection(w, r, "revoking share token")
+	if !ok {
+		return
+	}
+
+	err := ch.CollectionService.RevokeCollectionShareToken(collection.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when revoking share token"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Share token is successfully revoked"})
+}
+
+// viewableCollection returns the collection a request refers to should it be owned by the
+// user making the request or be public. A response is written and false is returned should
+// it not be found
+func (ch CollectionHandler) viewableCollection(w http.ResponseWriter, r *http.Request) (snippets.Collection, bool) {
+	collectionID := mux.Vars(r)["collectionID"]
+	var collection snippets.Collection
+	var err error
+	if userInfo, authErr := ch.Authenticator.GetAuthorizationInfo(r); authErr == nil {
+		collection, err = ch.CollectionService.Collection(userInfo.UserID, collectionID)
+	}
+	if err == nil && collection.ID == "" {
+		collection, err = ch.CollectionService.PublicCollection(collectionID)
+	}
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested collection"})
+		return collection, false
+	}
+	if collection.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested collection is not found"})
+		return collection, false
+	}
+	return collection, true
+}
+
+// sharedCollection returns the collection with the share token a request refers to. A
+// response is written and false is returned should it not be found
+func (ch CollectionHandler) sharedCollection(w http.ResponseWriter, r *http.Request) (snippets.Collection, bool) {
+	collection, err := ch.CollectionService.SharedCollection(mux.Vars(r)["shareToken"])
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested collection"})
+		return collection, false
+	}
+	if collection.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested collection is not found"})
+		return collection, false
+	}
+	return collection, true
+}
+
+// ownedCollection returns the collection a request refers to should it be owned by the user
+// making the request. A response mentioning the given action is written and false is
+// returned should it not be found
+func (ch CollectionHandler) ownedCollection(w http.ResponseWriter, r *http.Request, action string) (snippets.Collection, bool) {
+	userInfo, err := ch.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when " + action})
+		return snippets.Collection{}, false
+	}
+
+	collection, err := ch.CollectionService.Collection(userInfo.UserID, mux.Vars(r)["collectionID"])
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when " + action})
+		return collection, false
+	}
+	if collection.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested collection is not found"})
+		return collection, false
+	}
+	return collection, true
+}
+
+// respondCollectionSnippets responds with the snippets of the given collection
+func (ch CollectionHandler) respondCollectionSnippets(w http.ResponseWriter, collection snippets.Collection) {
+	collected, err := ch.CollectionService.CollectionSnippets(collection.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving collection snippets"})
+		return
+	}
+	createResponse(w, http.StatusOK, collected)
+}
+
+// areCollectable reports whether the user with the given userID is permitted to collect every
+// one of the given snippetIDs that is not already collected. As a collection is shared as a
+// whole, only the snippets the user is permitted to manage can be collected. A response is
+// written should any of them not be
+func (ch CollectionHandler) areCollectable(w http.ResponseWriter, userID string, snippetIDs []string, collected []string) bool {
+	alreadyCollected := make(map[string]bool)
+	for _, id := range collected {
+		alreadyCollected[id] = true
+	}
+
+	for _, id := range snippetIDs {
+		if alreadyCollected[id] {
+			continue
+		}
+		snippet, err := ch.SnippetService.Snippet(userID, id)
+		if err != nil {
+			createResponse(w, http.StatusInternalServerError, defaultResponse{
+				"An unexpected error occurred when checking collected snippets"})
+			return false
+		}
+		if snippet.ID == "" || !snippet.Role.CanManage() {
+			createResponse(w, http.StatusBadRequest, defaultResponse{
+				"Error, snippet " + id + " is not found or is not yours to collect"})
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,275 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// commentUpdateRequest represents the request body editing a comment, of which only the
+// body can be changed
+type commentUpdateRequest struct {
+	Body string `json:"body"`
+}
+
+// handleGetComments
+func (sh SnippetHandler) handleGetComments(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving comments"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	comments, err := sh.SnippetService.Comments(snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving comments"})
+		return
+	}
+	createResponse(w, http.StatusOK, comments)
+}
+
+// handleGetCommentByID
+func (sh SnippetHandler) handleGetCommentByID(w http.ResponseWriter, r *http.Request) {
+	snippetID, commentID := mux.Vars(r)["snippetID"], mux.Vars(r)["commentID"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested comment"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	comment, err := sh.SnippetService.Comment(snippetID, commentID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested comment"})
+		return
+	}
+	if comment.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested comment is not found"})
+		return
+	}
+	createResponse(w, http.StatusOK, comment)
+}
+
+// handleCreateComment
+func (sh SnippetHandler) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating comment"})
+		return
+	}
+
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating comment"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, snippet to comment on is not found"})
+		return
+	}
+
+	var newComment snippets.Comment
+	err = json.NewDecoder(r.Body).Decode(&newComment)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Invalid request body"})
+		return
+	}
+
+	newComment.ID = ""
+	newComment.SnippetID = snippetID
+	newComment.Author = userInfo.UserID
+	// a comment anchored to a single line need not repeat it as its end line
+	if newComment.EndLine == nil {
+		newComment.EndLine = newComment.StartLine
+	}
+
+	err = newComment.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if newComment.ParentID != nil {
+		parent, err := sh.SnippetService.Comment(snippetID, *newComment.ParentID)
+		if err != nil {
+			createResponse(w, http.StatusInternalServerError, defaultResponse{
+				"An unexpected error occurred when creating comment"})
+			return
+		}
+		if parent.ID == "" {
+			createResponse(w, http.StatusBadRequest, defaultResponse{
+				"Error, the comment being replied to is not found on this snippet"})
+			return
+		}
+	}
+	if newComment.IsAnchored() && !sh.isValidAnchor(w, newComment) {
+		return
+	}
+
+	commentID, err := sh.SnippetService.CreateComment(newComment)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating comment"})
+		return
+	}
+
+	comment, err := sh.SnippetService.Comment(snippetID, commentID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting created comment"})
+		return
+	}
+	createResponse(w, http.StatusCreated, comment)
+}
+
+// handlePatchComment
+func (sh SnippetHandler) handlePatchComment(w http.ResponseWriter, r *http.Request) {
+	snippetID, commentID := mux.Vars(r)["snippetID"], mux.Vars(r)["commentID"]
+	commentToUpdate, ok := sh.authoredComment(w, r, snippetID, commentID, "updating comment")
+	if !ok {
+		return
+	}
+
+	var req commentUpdateRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&req)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"JSON could not be decoded, invalid request format supplied"})
+		return
+	}
+
+	commentToUpdate.Body = req.Body
+	err = commentToUpdate.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = sh.SnippetService.UpdateComment(commentToUpdate)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating comment"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Comment is successfully updated"})
+}
+
+// handleDeleteComment
+func (sh SnippetHandler) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	snippetID, commentID := mux.Vars(r)["snippetID"], mux.Vars(r)["commentID"]
+	if _, ok := sh.authoredComment(w, r, snippetID, commentID, "deleting comment"); !ok {
+		return
+	}
+
+	err := sh.SnippetService.DeleteComment(snippetID, commentID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting comment"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Comment is successfully deleted"})
+}
+
+// authoredComment returns the comment with the given commentID on the snippet with the given
+// snippetID should it still be viewable by and have been written by the user making the
+// request. A response mentioning the given action is written and false is returned should
+// it not be found or not be written by the user
+func (sh SnippetHandler) authoredComment(w http.ResponseWriter, r *http.Request, snippetID string, commentID string,
+	action string) (snippets.Comment, bool) {
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when " + action})
+		return snippets.Comment{}, false
+	}
+
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when " + action})
+		return snippets.Comment{}, false
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return snippets.Comment{}, false
+	}
+
+	comment, err := sh.SnippetService.Comment(snippetID, commentID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when " + action})
+		return comment, false
+	}
+	if comment.ID == "" || comment.DeletedAt != nil {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested comment is not found"})
+		return comment, false
+	}
+	if comment.Author != userInfo.UserID {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the author of this comment is permitted to do this"})
+		return comment, false
+	}
+	return comment, true
+}
+
+// isValidAnchor reports whether the file and lines the given comment is anchored to exist in
+// the revision it is anchored to. A response is written should they not
+func (sh SnippetHandler) isValidAnchor(w http.ResponseWriter, comment snippets.Comment) bool {
+	revision, err := sh.SnippetService.Revision(comment.SnippetID, *comment.RevisionID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating comment"})
+		return false
+	}
+	if revision.ID == "" {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, the revision being commented on is not found on this snippet"})
+		return false
+	}
+
+	for _, file := range revision.Files {
+		if file.Filename != *comment.Filename {
+			continue
+		}
+		lines := strings.Count(file.Content, "\n")
+		if !strings.HasSuffix(file.Content, "\n") {
+			lines++
+		}
+		if *comment.EndLine > lines {
+			createResponse(w, http.StatusBadRequest, defaultResponse{
+				"Error, the lines being commented on are past the end of the file"})
+			return false
+		}
+		return true
+	}
+	createResponse(w, http.StatusBadRequest, defaultResponse{
+		"Error, the file being commented on is not found in the revision"})
+	return false
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// The size in pixels of the iframe a snippet is embedded in, unless a consumer asks for less
+const (
+	defaultEmbedWidth  = 640
+	defaultEmbedHeight = 400
+)
+
+// embeddableURLPattern matches the path of a URL pointing to a public snippet by its UUID or
+// to an unlisted snippet by its share token, capturing whether it is shared and its key
+var embeddableURLPattern = regexp.MustCompile(
+	`^/api/v0/snippets/(?:(shared)/([A-Za-z0-9_-]+)|([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}))(?:/.*)?$`)
+
+// oEmbedResponse represents the response of an oEmbed provider for content of the rich type
+type oEmbedResponse struct {
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	Title        string `json:"title"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// handleGetEmbed
+func (sh SnippetHandler) handleGetEmbed(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := sh.embeddableSnippet(w, r)
+	if !ok {
+		return
+	}
+	opts, err := sh.parseHighlightOptions(r)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "embedding snippet") {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><base target="_blank"><title>` +
+		html.EscapeString(snippet.Filename) + `</title></head><body style="margin:0">`)
+	err = sh.writeWidget(&buf, r, snippet, opts)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when embedding snippet"})
+		return
+	}
+	buf.WriteString(`</body></html>`)
+
+	// the widget is meant to be framed by any site, but may not load anything itself
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors *")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// handleGetEmbedScript
+func (sh SnippetHandler) handleGetEmbedScript(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := sh.embeddableSnippet(w, r)
+	if !ok {
+		return
+	}
+	opts, err := sh.parseHighlightOptions(r)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "embedding snippet") {
+		return
+	}
+
+	var widget bytes.Buffer
+	err = sh.writeWidget(&widget, r, snippet, opts)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when embedding snippet"})
+		return
+	}
+	// json.Marshal escapes <, > and & so the widget cannot end the script it is written by
+	literal, err := json.Marshal(widget.String())
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when embedding snippet"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("document.write(" + string(literal) + ");\n"))
+}
+
+// handleGetOEmbed
+func (sh SnippetHandler) handleGetOEmbed(w http.ResponseWriter, r *http.Request) {
+	if format := r.URL.Query().Get("format"); format != "" && format != "json" {
+		createResponse(w, http.StatusNotImplemented, defaultResponse{
+			"Error, only the json format is supported"})
+		return
+	}
+
+	width, err := parseMaxDimension(r, "maxwidth", defaultEmbedWidth)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+	height, err := parseMaxDimension(r, "maxheight", defaultEmbedHeight)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+
+	target, err := url.Parse(r.URL.Query().Get("url"))
+	if err != nil || target.Host == "" {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, url must be an absolute URL"})
+		return
+	}
+	base, err := url.Parse(sh.BaseURL)
+	if err != nil || !strings.EqualFold(target.Host, base.Host) ||
+		!strings.HasPrefix(target.Path, strings.TrimSuffix(base.Path, "/")+"/") {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	matches := embeddableURLPattern.FindStringSubmatch(strings.TrimPrefix(target.Path, strings.TrimSuffix(base.Path, "/")))
+	if matches == nil {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	var snippet snippets.Snippet
+	if matches[1] != "" {
+		snippet, err = sh.SnippetService.SharedSnippet(matches[2])
+	} else {
+		snippet, err = sh.SnippetService.PublicSnippet(matches[3])
+	}
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	embedURL := sh.snippetURL(snippet, matches[2]) + "/embed"
+	createResponse(w, http.StatusOK, oEmbedResponse{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        snippet.Filename,
+		ProviderName: "Snippets",
+		ProviderURL:  sh.BaseURL,
+		HTML: `<iframe src="` + html.EscapeString(embedURL) + `" width="` + strconv.Itoa(width) + `" height="` +
+			strconv.Itoa(height) + `" frameborder="0" title="` + html.EscapeString(snippet.Filename) + `"></iframe>`,
+		Width:  width,
+		Height: height,
+	})
+}
+
+// embeddableSnippet returns the snippet a request to embed one refers to, which is either a
+// public snippet given its UUID or an unlisted snippet given its share token. A response is
+// written and false is returned should the snippet not be found
+func (sh SnippetHandler) embeddableSnippet(w http.ResponseWriter, r *http.Request) (snippets.Snippet, bool) {
+	var snippet snippets.Snippet
+	var err error
+	if shareToken, ok := mux.Vars(r)["shareToken"]; ok {
+		snippet, err = sh.SnippetService.SharedSnippet(shareToken)
+	} else {
+		snippet, err = sh.SnippetService.PublicSnippet(mux.Vars(r)["snippetID"])
+	}
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when embedding snippet"})
+		return snippet, false
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return snippet, false
+	}
+	return snippet, true
+}
+
+// writeWidget writes the markup of the widget a snippet is embedded as to w. The widget is
+// styled inline so that it does not depend on the stylesheets of the page it is placed on
+func (sh SnippetHandler) writeWidget(w io.Writer, r *http.Request, snippet snippets.Snippet, opts snippets.HighlightOptions) error {
+	io.WriteString(w, `<div style="border:1px solid #ddd;border-radius:4px;overflow:auto;font-family:sans-serif;font-size:13px">`)
+	err := sh.highlightSnippet(w, snippet, opts)
+	if err != nil {
+		return err
+	}
+	rawURL := sh.snippetURL(snippet, mux.Vars(r)["shareToken"]) + "/raw"
+	io.WriteString(w, `<div style="padding:4px 8px;border-top:1px solid #ddd;background:#f7f7f7"><a href="`+
+		html.EscapeString(rawURL)+`" target="_blank" rel="noopener">view raw</a></div></div>`)
+	return nil
+}
+
+// snippetURL returns the absolute URL of the given snippet, which is addressed by its share
+// token should one be given
+func (sh SnippetHandler) snippetURL(snippet snippets.Snippet, shareToken string) string {
+	base := strings.TrimSuffix(sh.BaseURL, "/")
+	if shareToken != "" {
+		return base + "/api/v0/snippets/shared/" + url.PathEscape(shareToken)
+	}
+	return base + "/api/v0/snippets/" + snippet.ID
+}
+
+// parseMaxDimension reads the positive integer query parameter with the given name and
+// returns it should it be smaller than the given default, else, the default is returned
+func parseMaxDimension(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	max, err := strconv.Atoi(value)
+	if err != nil || max < 1 {
+		return 0, errors.New("Error, " + name + " must be a positive integer")
+	}
+	if max < defaultValue {
+		return max, nil
+	}
+	return defaultValue, nil
+}
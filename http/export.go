@@ -0,0 +1,137 @@
+package http
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/chuabingquan/snippets"
+)
+
+// archiveWriter writes files into an archive of a particular format
+type archiveWriter interface {
+	WriteFile(name string, content []byte, modTime time.Time) error
+	Close() error
+}
+
+// zipArchive writes files into a zip archive
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+// WriteFile adds a compressed file with the given name and content to the archive
+func (a zipArchive) WriteFile(name string, content []byte, modTime time.Time) error {
+	fw, err := a.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(content)
+	return err
+}
+
+// Close finishes writing the archive without closing the underlying writer
+func (a zipArchive) Close() error {
+	return a.zw.Close()
+}
+
+// tarGzArchive writes files into a gzip compressed tar archive
+type tarGzArchive struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+// WriteFile adds a file with the given name and content to the archive
+func (a tarGzArchive) WriteFile(name string, content []byte, modTime time.Time) error {
+	err := a.tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: modTime})
+	if err != nil {
+		return err
+	}
+	_, err = a.tw.Write(content)
+	return err
+}
+
+// Close finishes writing the archive without closing the underlying writer
+func (a tarGzArchive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gw.Close()
+}
+
+// exportedSnippet represents the metadata of a snippet as listed in the manifest of an export.
+// Content is left empty to leave out the content of the snippet, which is archived as a file
+type exportedSnippet struct {
+	snippets.Snippet
+	Content string   `json:"content,omitempty"`
+	Files   []string `json:"files"`
+}
+
+// exportManifest represents the manifest.json file describing the snippets in an export
+type exportManifest struct {
+	ExportedAt time.Time         `json:"exportedAt"`
+	Snippets   []exportedSnippet `json:"snippets"`
+}
+
+// handleExportSnippets
+func (sh SnippetHandler) handleExportSnippets(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when exporting snippets"})
+		return
+	}
+
+	var archive archiveWriter
+	var contentType, filename string
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "zip":
+		archive = zipArchive{zip.NewWriter(w)}
+		contentType, filename = "application/zip", "snippets.zip"
+	case "tar.gz":
+		gw := gzip.NewWriter(w)
+		archive = tarGzArchive{gw, tar.NewWriter(gw)}
+		contentType, filename = "application/gzip", "snippets.tar.gz"
+	default:
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, format must be either zip or tar.gz"})
+		return
+	}
+
+	// the archive is streamed as it is written, so an error past this point can only be
+	// signalled by aborting the response before the archive is complete
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	manifest := exportManifest{ExportedAt: time.Now().UTC(), Snippets: []exportedSnippet{}}
+	err = sh.SnippetService.ExportSnippets(userInfo.UserID, func(s snippets.Snippet, files []snippets.SnippetFile) error {
+		exported := exportedSnippet{Snippet: s, Files: make([]string, len(files))}
+		for i, file := range files {
+			exported.Files[i] = file.Filename
+			err := archive.WriteFile(s.ID+"/"+file.Filename, []byte(file.Content), s.UpdatedAt)
+			if err != nil {
+				return err
+			}
+		}
+		manifest.Snippets = append(manifest.Snippets, exported)
+		return nil
+	})
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if err = archive.WriteFile("manifest.json", manifestJSON, manifest.ExportedAt); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if err = archive.Close(); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
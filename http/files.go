@@ -0,0 +1,281 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// handleGetSnippetFiles
+func (sh SnippetHandler) handleGetSnippetFiles(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving snippet files"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	files, err := sh.SnippetService.SnippetFiles(snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving snippet files"})
+		return
+	}
+	createResponse(w, http.StatusOK, withoutContent(snippet, files))
+}
+
+// handleGetSnippetFile
+func (sh SnippetHandler) handleGetSnippetFile(w http.ResponseWriter, r *http.Request) {
+	snippetID, fileName := mux.Vars(r)["snippetID"], mux.Vars(r)["fileName"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet file"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	file, err := sh.SnippetService.SnippetFile(snippetID, fileName)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet file"})
+		return
+	}
+	if file == (snippets.SnippetFile{}) {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet file is not found"})
+		return
+	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "getting requested snippet file") {
+		return
+	}
+	createResponse(w, http.StatusOK, file)
+}
+
+// handleAddSnippetFile
+func (sh SnippetHandler) handleAddSnippetFile(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when adding snippet file"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when adding snippet file"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !snippet.Role.CanEdit() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, you are not permitted to edit this snippet"})
+		return
+	}
+
+	var newFile snippets.SnippetFile
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize(sh.MaxContentSize))
+	err = json.NewDecoder(r.Body).Decode(&newFile)
+	if isRequestBodyTooLarge(err) || int64(len(newFile.Content)) > sh.MaxContentSize {
+		respondContentTooLarge(w, sh.MaxContentSize)
+		return
+	}
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Invalid request body"})
+		return
+	}
+
+	newFile.SnippetID = snippetID
+
+	err = newFile.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	existingFile, err := sh.SnippetService.SnippetFile(snippetID, newFile.Filename)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when adding snippet file"})
+		return
+	}
+	if existingFile != (snippets.SnippetFile{}) {
+		createResponse(w, http.StatusConflict, defaultResponse{
+			"Error, a file with the same filename already exists in the snippet"})
+		return
+	}
+
+	err = sh.SnippetService.AddSnippetFile(userInfo.UserID, newFile)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when adding snippet file"})
+		return
+	}
+	createResponse(w, http.StatusCreated, defaultResponse{"Snippet file is successfully added"})
+}
+
+// handlePatchSnippetFile
+func (sh SnippetHandler) handlePatchSnippetFile(w http.ResponseWriter, r *http.Request) {
+	snippetID, fileName := mux.Vars(r)["snippetID"], mux.Vars(r)["fileName"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating snippet file"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating snippet file"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !snippet.Role.CanEdit() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, you are not permitted to edit this snippet"})
+		return
+	}
+
+	fileToUpdate, err := sh.SnippetService.SnippetFile(snippetID, fileName)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating snippet file"})
+		return
+	}
+	if fileToUpdate == (snippets.SnippetFile{}) {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, snippet file to update is not found"})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize(sh.MaxContentSize))
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&fileToUpdate)
+	if isRequestBodyTooLarge(err) || int64(len(fileToUpdate.Content)) > sh.MaxContentSize {
+		respondContentTooLarge(w, sh.MaxContentSize)
+		return
+	}
+	if err != nil || fileToUpdate.SnippetID != snippetID {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"JSON could not be decoded, invalid request format supplied"})
+		return
+	}
+
+	err = fileToUpdate.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if fileToUpdate.Filename != fileName {
+		existingFile, err := sh.SnippetService.SnippetFile(snippetID, fileToUpdate.Filename)
+		if err != nil {
+			createResponse(w, http.StatusInternalServerError, defaultResponse{
+				"An unexpected error occurred when updating snippet file"})
+			return
+		}
+		if existingFile != (snippets.SnippetFile{}) {
+			createResponse(w, http.StatusConflict, defaultResponse{
+				"Error, a file with the same filename already exists in the snippet"})
+			return
+		}
+	}
+
+	err = sh.SnippetService.UpdateSnippetFile(userInfo.UserID, fileName, fileToUpdate)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating snippet file"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Snippet file is successfully updated"})
+}
+
+// handleDeleteSnippetFile
+func (sh SnippetHandler) handleDeleteSnippetFile(w http.ResponseWriter, r *http.Request) {
+	snippetID, fileName := mux.Vars(r)["snippetID"], mux.Vars(r)["fileName"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting snippet file"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting snippet file"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !snippet.Role.CanEdit() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, you are not permitted to edit this snippet"})
+		return
+	}
+	if snippet.Filename == fileName {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, the primary file of a snippet can only be removed by deleting the snippet"})
+		return
+	}
+
+	fileToDelete, err := sh.SnippetService.SnippetFile(snippetID, fileName)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting snippet file"})
+		return
+	}
+	if fileToDelete == (snippets.SnippetFile{}) {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, snippet file to delete is not found"})
+		return
+	}
+
+	err = sh.SnippetService.RemoveSnippetFile(userInfo.UserID, snippetID, fileName)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting snippet file"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Snippet file is successfully deleted"})
+}
+
+// withoutContent leaves out the content of the given files of a snippet that is burnt once
+// read, as listing its files does not count as reading it
+func withoutContent(snippet snippets.Snippet, files []snippets.SnippetFile) []snippets.SnippetFile {
+	if !burnsOnRead(snippet) {
+		return files
+	}
+	for i := range files {
+		files[i].Content = ""
+	}
+	return files
+}
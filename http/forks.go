@@ -0,0 +1,104 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// forksResponse represents the fork lineage of a snippet
+type forksResponse struct {
+	Parent *string            `json:"parent"`
+	Forks  []snippets.Snippet `json:"forks"`
+}
+
+// handleForkSnippet
+func (sh SnippetHandler) handleForkSnippet(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when forking snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, snippet to fork is not found"})
+		return
+	}
+	sh.forkSnippet(w, r, snippet)
+}
+
+// handleForkSharedSnippet
+func (sh SnippetHandler) handleForkSharedSnippet(w http.ResponseWriter, r *http.Request) {
+	shareToken := mux.Vars(r)["shareToken"]
+	snippet, err := sh.SnippetService.SharedSnippet(shareToken)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when forking snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, snippet to fork is not found"})
+		return
+	}
+	sh.forkSnippet(w, r, snippet)
+}
+
+// forkSnippet forks the given snippet into the account of the user making the request and
+// responds with the newly created fork. Forking a snippet reads its content
+func (sh SnippetHandler) forkSnippet(w http.ResponseWriter, r *http.Request, snippet snippets.Snippet) {
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when forking snippet"})
+		return
+	}
+
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "forking snippet") {
+		return
+	}
+
+	forkID, err := sh.SnippetService.ForkSnippet(userInfo.UserID, snippet.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when forking snippet"})
+		return
+	}
+
+	fork, err := sh.SnippetService.Snippet(userInfo.UserID, forkID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when forking snippet"})
+		return
+	}
+	createResponse(w, http.StatusCreated, fork)
+}
+
+// handleGetForks
+func (sh SnippetHandler) handleGetForks(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving forks"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	// anonymous users are only shown public forks
+	userInfo, _ := sh.Authenticator.GetAuthorizationInfo(r)
+	forks, err := sh.SnippetService.Forks(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving forks"})
+		return
+	}
+	createResponse(w, http.StatusOK, forksResponse{snippet.ForkedFrom, forks})
+}
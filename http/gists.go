@@ -0,0 +1,681 @@
+package http
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// GistHandler is a sub-router that exposes snippets through the core endpoints of the GitHub
+// Gist REST API, so that existing gist clients can be pointed at this server. A public gist is
+// a public snippet while a secret gist is a private snippet
+type GistHandler struct {
+	*mux.Router
+	SnippetService snippets.SnippetService
+	UserService    snippets.UserService
+	Authenticator  Authenticator
+	MaxContentSize int64
+	BaseURL        string
+}
+
+// NewGistHandler constructs a new GistHandler given a SnippetService and UserService
+// implementation, the maximum size in bytes of a snippet's content and the URL the API is
+// publicly reachable at
+func NewGistHandler(ss snippets.SnippetService, us snippets.UserService, auth Authenticator, maxContentSize int64,
+	baseURL string) *GistHandler {
+	h := &GistHandler{
+		Router:         mux.NewRouter(),
+		SnippetService: ss,
+		UserService:    us,
+		Authenticator:  auth,
+		MaxContentSize: maxContentSize,
+		BaseURL:        baseURL,
+	}
+
+	verifyUser := verifyRoute(auth)
+	identifyUser := identifyRoute(auth)
+
+	h.Handle("/api/v0/gists", Adapt(http.HandlerFunc(h.handleGetGists), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/gists", Adapt(http.HandlerFunc(h.handleCreateGist), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/gists/public", Adapt(http.HandlerFunc(h.handleGetPublicGists))).Methods("GET")
+	h.Handle("/api/v0/gists/starred", Adapt(http.HandlerFunc(h.handleGetStarredGists), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/gists/{gistID}", Adapt(http.HandlerFunc(h.handleGetGist), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/gists/{gistID}", Adapt(http.HandlerFunc(h.handlePatchGist), verifyUser)).Methods("PATCH")
+	h.Handle("/api/v0/gists/{gistID}", Adapt(http.HandlerFunc(h.handleDeleteGist), verifyUser)).Methods("DELETE")
+	h.Handle("/api/v0/gists/{gistID}/star", Adapt(http.HandlerFunc(h.handleCheckGistStar), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/gists/{gistID}/star", Adapt(http.HandlerFunc(h.handleStarGist), verifyUser)).Methods("PUT")
+	h.Handle("/api/v0/gists/{gistID}/star", Adapt(http.HandlerFunc(h.handleUnstarGist), verifyUser)).Methods("DELETE")
+	h.Handle("/api/v0/gists/{gistID}/forks", Adapt(http.HandlerFunc(h.handleForkGist), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/gists/{gistID}/forks", Adapt(http.HandlerFunc(h.handleGetGistForks), identifyUser)).Methods("GET")
+
+	return h
+}
+
+// gist represents a snippet in the format of the Gist API
+type gist struct {
+	ID          string              `json:"id"`
+	URL         string              `json:"url"`
+	ForksURL    string              `json:"forks_url"`
+	HTMLURL     string              `json:"html_url"`
+	Description string              `json:"description"`
+	Public      bool                `json:"public"`
+	Owner       *gistOwner          `json:"owner,omitempty"`
+	Files       map[string]gistFile `json:"files"`
+	Comments    int                 `json:"comments"`
+	Truncated   bool                `json:"truncated"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// gistOwner represents the owner of a gist in the format of the Gist API
+type gistOwner struct {
+	Login string `json:"login"`
+}
+
+// gistFile represents a file of a gist in the format of the Gist API
+type gistFile struct {
+	Filename  string `json:"filename"`
+	Type      string `json:"type"`
+	Language  string `json:"language"`
+	RawURL    string `json:"raw_url"`
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated"`
+	Content   string `json:"content,omitempty"`
+}
+
+// gistRequest represents the body of a request to create or edit a gist. A file mapped to
+// null is deleted when editing a gist
+type gistRequest struct {
+	Description *string                     `json:"description"`
+	Public      *bool                       `json:"public"`
+	Files       map[string]*gistFileRequest `json:"files"`
+}
+
+// gistFileRequest represents a file to be created or edited in a request to create or
+// edit a gist
+type gistFileRequest struct {
+	Filename *string `json:"filename"`
+	Content  *string `json:"content"`
+}
+
+// handleGetGists
+func (gh GistHandler) handleGetGists(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving gists"})
+		return
+	}
+
+	filter, opts, ok := parseGistListOptions(w, r)
+	if !ok {
+		return
+	}
+	snippets, page, err := gh.SnippetService.Snippets(userInfo.UserID, filter, opts)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving gists"})
+		return
+	}
+	setPageHeaders(w, r, page)
+	gh.respondGists(w, snippets)
+}
+
+// handleGetPublicGists
+func (gh GistHandler) handleGetPublicGists(w http.ResponseWriter, r *http.Request) {
+	filter, opts, ok := parseGistListOptions(w, r)
+	if !ok {
+		return
+	}
+	snippets, page, err := gh.SnippetService.PublicSnippets(filter, opts)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving gists"})
+		return
+	}
+	setPageHeaders(w, r, page)
+	gh.respondGists(w, snippets)
+}
+
+// handleGetStarredGists
+func (gh GistHandler) handleGetStarredGists(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving starred gists"})
+		return
+	}
+
+	snippets, err := gh.SnippetService.StarredSnippets(userInfo.UserID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving starred gists"})
+		return
+	}
+	gh.respondGists(w, snippets)
+}
+
+// handleGetGist
+func (gh GistHandler) handleGetGist(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := gh.viewableGist(w, r)
+	if !ok || !readSnippet(w, r, gh.SnippetService, snippet, "gist", "getting requested gist") {
+		return
+	}
+	gh.respondGist(w, http.StatusOK, snippet)
+}
+
+// handleCreateGist
+func (gh GistHandler) handleCreateGist(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating gist"})
+		return
+	}
+
+	req, ok := gh.decodeGistRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// files are given as an object, so the first of them by filename is made the primary file
+	var files []snippets.SnippetFile
+	for name, f := range req.Files {
+		if f == nil || f.Content == nil {
+			createResponse(w, http.StatusUnprocessableEntity, defaultResponse{
+				"Error, every file of a gist has to have content"})
+			return
+		}
+		files = append(files, snippets.SnippetFile{Filename: name, Content: *f.Content})
+	}
+	if len(files) < 1 {
+		createResponse(w, http.StatusUnprocessableEntity, defaultResponse{
+			"Error, a gist has to have at least one file"})
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+
+	newSnippet := snippets.Snippet{
+		Filename:   files[0].Filename,
+		Content:    files[0].Content,
+		Visibility: snippets.VisibilityPrivate,
+		Language:   snippets.DetectLanguage(files[0].Filename, files[0].Content),
+		Owner:      userInfo.UserID,
+	}
+	if req.Description != nil {
+		newSnippet.Description = *req.Description
+	}
+	if req.Public != nil && *req.Public {
+		newSnippet.Visibility = snippets.VisibilityPublic
+	}
+
+	err = newSnippet.Validate()
+	if err != nil {
+		createResponse(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	for _, file := range files[1:] {
+		if err = file.Validate(); err != nil {
+			createResponse(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	snippetID, err := gh.SnippetService.CreateSnippet(newSnippet, files[1:])
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating gist"})
+		return
+	}
+
+	createdSnippet, err := gh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating gist"})
+		return
+	}
+	w.Header().Set("Location", gh.gistURL(createdSnippet.ID))
+	gh.respondGist(w, http.StatusCreated, createdSnippet)
+}
+
+// handlePatchGist
+func (gh GistHandler) handlePatchGist(w http.ResponseWriter, r *http.Request) {
+	gistID := mux.Vars(r)["gistID"]
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating gist"})
+		return
+	}
+
+	snippet, err := gh.SnippetService.Snippet(userInfo.UserID, gistID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating gist"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, gist to update is not found"})
+		return
+	}
+	if !snippet.Role.CanEdit() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, you are not permitted to edit this gist"})
+		return
+	}
+
+	req, ok := gh.decodeGistRequest(w, r)
+	if !ok {
+		return
+	}
+
+	existing, err := gh.SnippetService.SnippetFiles(snippet.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating gist"})
+		return
+	}
+	existingContent := make(map[string]string)
+	for _, file := range existing {
+		existingContent[file.Filename] = file.Content
+	}
+
+	names := make([]string, 0, len(req.Files))
+	for name := range req.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// every change is checked before any of them is made, a file cannot be given the name of
+	// another file of the gist or that of another file of the request
+	var changes []snippets.SnippetFileChange
+	targets := make(map[string]bool)
+	for _, name := range names {
+		f := req.Files[name]
+		content, exists := existingContent[name]
+		if f == nil {
+			if name == snippet.Filename {
+				createResponse(w, http.StatusUnprocessableEntity, defaultResponse{
+					"Error, the first file of a gist cannot be deleted"})
+				return
+			}
+			if exists {
+				changes = append(changes, snippets.SnippetFileChange{Filename: name})
+			}
+			continue
+		}
+		if !exists && f.Content == nil {
+			createResponse(w, http.StatusUnprocessableEntity, defaultResponse{
+				"Error, a new file of a gist has to have content"})
+			return
+		}
+
+		file := snippets.SnippetFile{Filename: name, Content: content}
+		if f.Filename != nil {
+			file.Filename = *f.Filename
+		}
+		if f.Content != nil {
+			file.Content = *f.Content
+		}
+		if err = file.Validate(); err != nil {
+			createResponse(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		if _, taken := existingContent[file.Filename]; (taken && file.Filename != name) || targets[file.Filename] {
+			createResponse(w, http.StatusUnprocessableEntity, defaultResponse{
+				"Error, a file with the same filename already exists in the gist"})
+			return
+		}
+		targets[file.Filename] = true
+		changes = append(changes, snippets.SnippetFileChange{Filename: name, File: &file})
+	}
+
+	if req.Description != nil {
+		snippet.Description = *req.Description
+		if err = snippet.Validate(); err != nil {
+			createResponse(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	err = gh.SnippetService.EditSnippet(userInfo.UserID, snippet, changes)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating gist"})
+		return
+	}
+
+	updatedSnippet, err := gh.SnippetService.Snippet(userInfo.UserID, snippet.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating gist"})
+		return
+	}
+	gh.respondGist(w, http.StatusOK, updatedSnippet)
+}
+
+// handleDeleteGist
+func (gh GistHandler) handleDeleteGist(w http.ResponseWriter, r *http.Request) {
+	gistID := mux.Vars(r)["gistID"]
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting gist"})
+		return
+	}
+
+	snippet, err := gh.SnippetService.Snippet(userInfo.UserID, gistID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting gist"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, gist to delete is not found"})
+		return
+	}
+	if !snippet.Role.CanManage() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owner of this gist is permitted to do this"})
+		return
+	}
+
+	err = gh.SnippetService.DeleteSnippet(userInfo.UserID, gistID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting gist"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCheckGistStar
+func (gh GistHandler) handleCheckGistStar(w http.ResponseWriter, r *http.Request) {
+	gistID := mux.Vars(r)["gistID"]
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when checking gist star"})
+		return
+	}
+
+	starred, err := gh.SnippetService.StarredSnippet(userInfo.UserID, gistID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when checking gist star"})
+		return
+	}
+	if starred.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{"Error, gist is not starred"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleStarGist
+func (gh GistHandler) handleStarGist(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := gh.viewableGist(w, r)
+	if !ok {
+		return
+	}
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when starring gist"})
+		return
+	}
+
+	err = gh.SnippetService.StarSnippet(userInfo.UserID, snippet.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when starring gist"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUnstarGist
+func (gh GistHandler) handleUnstarGist(w http.ResponseWriter, r *http.Request) {
+	gistID := mux.Vars(r)["gistID"]
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when unstarring gist"})
+		return
+	}
+
+	err = gh.SnippetService.UnstarSnippet(userInfo.UserID, gistID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when unstarring gist"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleForkGist
+func (gh GistHandler) handleForkGist(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := gh.viewableGist(w, r)
+	if !ok || !readSnippet(w, r, gh.SnippetService, snippet, "gist", "forking gist") {
+		return
+	}
+	userInfo, err := gh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when forking gist"})
+		return
+	}
+
+	forkID, err := gh.SnippetService.ForkSnippet(userInfo.UserID, snippet.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when forking gist"})
+		return
+	}
+	fork, err := gh.SnippetService.Snippet(userInfo.UserID, forkID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when forking gist"})
+		return
+	}
+	w.Header().Set("Location", gh.gistURL(fork.ID))
+	gh.respondGist(w, http.StatusCreated, fork)
+}
+
+// handleGetGistForks
+func (gh GistHandler) handleGetGistForks(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := gh.viewableGist(w, r)
+	if !ok {
+		return
+	}
+
+	userID := ""
+	if userInfo, err := gh.Authenticator.GetAuthorizationInfo(r); err == nil {
+		userID = userInfo.UserID
+	}
+	forks, err := gh.SnippetService.Forks(userID, snippet.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving gist forks"})
+		return
+	}
+	gh.respondGists(w, forks)
+}
+
+// viewableGist returns the snippet of the gist a request refers to should it be owned by the
+// user making the request or be public. A response is written and false is returned should
+// it not be found
+func (gh GistHandler) viewableGist(w http.ResponseWriter, r *http.Request) (snippets.Snippet, bool) {
+	snippet, err := viewableSnippet(gh.SnippetService, gh.Authenticator, r, mux.Vars(r)["gistID"])
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested gist"})
+		return snippet, false
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested gist is not found"})
+		return snippet, false
+	}
+	return snippet, true
+}
+
+// parseGistListOptions reads the parameters of a request listing gists, which accepts the
+// per_page and since parameters of the Gist API alongside the usual list parameters. A
+// response is written and false is returned should any of them be invalid
+func parseGistListOptions(w http.ResponseWriter, r *http.Request) (snippets.SnippetFilter, snippets.ListOptions, bool) {
+	opts, err := parseListOptions(r, "updated")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return snippets.SnippetFilter{}, opts, false
+	}
+	// gists are listed from the most recently updated by default, as the Gist API does
+	if r.URL.Query().Get("order") == "" {
+		opts.Descending = true
+	}
+	if perPage := r.URL.Query().Get("per_page"); perPage != "" {
+		limit, err := strconv.Atoi(perPage)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			createResponse(w, http.StatusBadRequest, defaultResponse{
+				"Error, per_page must be an integer from 1 to " + strconv.Itoa(maxPageLimit)})
+			return snippets.SnippetFilter{}, opts, false
+		}
+		opts.Limit = limit
+	}
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return snippets.SnippetFilter{}, opts, false
+	}
+	return snippets.SnippetFilter{UpdatedSince: since}, opts, true
+}
+
+// decodeGistRequest decodes the body of a request to create or edit a gist. A response is
+// written and false is returned should it be invalid
+func (gh GistHandler) decodeGistRequest(w http.ResponseWriter, r *http.Request) (gistRequest, bool) {
+	var req gistRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize(gh.MaxContentSize))
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if isRequestBodyTooLarge(err) {
+		respondContentTooLarge(w, gh.MaxContentSize)
+		return req, false
+	}
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Problems parsing JSON"})
+		return req, false
+	}
+	for _, f := range req.Files {
+		if f != nil && f.Content != nil && int64(len(*f.Content)) > gh.MaxContentSize {
+			respondContentTooLarge(w, gh.MaxContentSize)
+			return req, false
+		}
+	}
+	return req, true
+}
+
+// respondGist responds with the given snippet as a gist, including the content of its files
+func (gh GistHandler) respondGist(w http.ResponseWriter, status int, snippet snippets.Snippet) {
+	g, err := gh.toGist(snippet, true, make(map[string]*gistOwner))
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested gist"})
+		return
+	}
+	createResponse(w, status, g)
+}
+
+// respondGists responds with the given snippets as gists, leaving out the content of their
+// files as the Gist API does when listing gists
+func (gh GistHandler) respondGists(w http.ResponseWriter, snippetSlice []snippets.Snippet) {
+	owners := make(map[string]*gistOwner)
+	gists := make([]gist, len(snippetSlice))
+	for i, snippet := range snippetSlice {
+		g, err := gh.toGist(snippet, false, owners)
+		if err != nil {
+			createResponse(w, http.StatusInternalServerError, defaultResponse{
+				"An unexpected error occurred when retrieving gists"})
+			return
+		}
+		gists[i] = g
+	}
+	createResponse(w, http.StatusOK, gists)
+}
+
+// toGist converts a snippet and its files into a gist. Owners already looked up are kept in
+// the given map by their user ID so that they are looked up once for a list of gists
+func (gh GistHandler) toGist(snippet snippets.Snippet, withContent bool, owners map[string]*gistOwner) (gist, error) {
+	g := gist{
+		ID:          snippet.ID,
+		URL:         gh.gistURL(snippet.ID),
+		ForksURL:    gh.gistURL(snippet.ID) + "/forks",
+		HTMLURL:     gh.snippetURL(snippet.ID),
+		Description: snippet.Description,
+		Public:      snippet.Visibility == snippets.VisibilityPublic,
+		Files:       make(map[string]gistFile),
+		CreatedAt:   snippet.CreatedAt,
+		UpdatedAt:   snippet.UpdatedAt,
+	}
+
+	// an organization's snippet has no owner once the user who created it is purged
+	owner, ok := owners[snippet.Owner]
+	if !ok && snippet.Owner != "" {
+		user, err := gh.UserService.User(snippet.Owner)
+		if err != nil {
+			return g, err
+		}
+		if user != (snippets.User{}) {
+			owner = &gistOwner{Login: user.Username}
+		}
+		owners[snippet.Owner] = owner
+	}
+	g.Owner = owner
+
+	files, err := gh.SnippetService.SnippetFiles(snippet.ID)
+	if err != nil {
+		return g, err
+	}
+	for i, file := range files {
+		language := snippet.Language
+		if i > 0 {
+			language = snippets.DetectLanguage(file.Filename, file.Content)
+		}
+		contentType := mime.TypeByExtension(path.Ext(file.Filename))
+		if contentType == "" {
+			contentType = "text/plain"
+		}
+		f := gistFile{
+			Filename: file.Filename,
+			Type:     strings.SplitN(contentType, ";", 2)[0],
+			Language: language,
+			RawURL:   gh.snippetURL(snippet.ID) + "/files/" + url.PathEscape(file.Filename) + "/raw",
+			Size:     len(file.Content),
+		}
+		if withContent {
+			f.Content = file.Content
+		}
+		g.Files[file.Filename] = f
+	}
+	return g, nil
+}
+
+// gistURL returns the absolute URL of the gist with the given ID
+func (gh GistHandler) gistURL(gistID string) string {
+	return strings.TrimSuffix(gh.BaseURL, "/") + "/api/v0/gists/" + gistID
+}
+
+// snippetURL returns the absolute URL of the snippet with the given ID
+func (gh GistHandler) snippetURL(snippetID string) string {
+	return strings.TrimSuffix(gh.BaseURL, "/") + "/api/v0/snippets/" + snippetID
+}
@@ -8,9 +8,12 @@ import (
 // Handler implements the http.Handler interface and acts as the main handler for the server,
 // redirecting requests to sub-handlers
 type Handler struct {
-	UserHandler    *UserHandler
-	SnippetHandler *SnippetHandler
-	AuthHandler    *AuthHandler
+	UserHandler       *UserHandler
+	SnippetHandler    *SnippetHandler
+	OrgHandler        *OrgHandler
+	CollectionHandler *CollectionHandler
+	AuthHandler       *AuthHandler
+	GistHandler       *GistHandler
 }
 
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -29,9 +32,23 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case "users":
 		h.UserHandler.ServeHTTP(w, r)
 		break
-	case "snippets":
+	case "snippets", "oembed":
 		h.SnippetHandler.ServeHTTP(w, r)
 		break
+	case "orgs":
+		h.OrgHandler.ServeHTTP(w, r)
+		break
+	case "collections":
+		h.CollectionHandler.ServeHTTP(w, r)
+		break
+	case "gists":
+		// the Gist API is optional and is only served should a GistHandler be given
+		if h.GistHandler == nil {
+			http.NotFound(w, r)
+			return
+		}
+		h.GistHandler.ServeHTTP(w, r)
+		break
 	default:
 		http.NotFound(w, r)
 	}
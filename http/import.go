@@ -0,0 +1,342 @@
+package http
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/chuabingquan/snippets"
+)
+
+// The largest archive that can be imported, which also bounds the total size of the files
+// it expands to, and the most files it may hold
+const (
+	maxImportSize    = 100 << 20
+	maxImportEntries = 5000
+)
+
+// The outcomes of importing a file
+const (
+	importCreated = "created"
+	importSkipped = "skipped"
+	importFailed  = "failed"
+)
+
+// importReportItem represents the outcome of importing a single file of an archive
+type importReportItem struct {
+	Path      string `json:"path"`
+	Status    string `json:"status"`
+	SnippetID string `json:"snippetId,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// archiveEntry represents a regular file read from an archive
+type archiveEntry struct {
+	path    string
+	content []byte
+}
+
+// importGroup represents the files of an archive that make up a single snippet, along with
+// the metadata given for it by the manifest, should there be any
+type importGroup struct {
+	snippet snippets.Snippet
+	entries []archiveEntry
+}
+
+// handleImportSnippets
+func (sh SnippetHandler) handleImportSnippets(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when importing snippets"})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	err = r.ParseMultipartForm(32 << 20)
+	if isRequestBodyTooLarge(err) {
+		createResponse(w, http.StatusRequestEntityTooLarge, defaultResponse{
+			"Archive exceeds the maximum size of 100 MiB"})
+		return
+	}
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Invalid request body, a multipart form is expected"})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("archive")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, an archive has to be uploaded in the archive field"})
+		return
+	}
+	defer file.Close()
+
+	entries, err := readArchive(file, header.Size)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+
+	groups, report, err := sh.groupImportEntries(entries)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+
+	imports := make([]snippets.SnippetImport, len(groups))
+	for i, group := range groups {
+		imports[i].Snippet = group.snippet
+		for _, entry := range group.entries[1:] {
+			imports[i].Files = append(imports[i].Files,
+				snippets.SnippetFile{Filename: path.Base(entry.path), Content: string(entry.content)})
+		}
+	}
+
+	results, err := sh.SnippetService.ImportSnippets(userInfo.UserID, imports)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when importing snippets"})
+		return
+	}
+
+	for i, result := range results {
+		for _, entry := range groups[i].entries {
+			item := importReportItem{Path: entry.path, Status: importCreated, SnippetID: result.Snippet.ID}
+			if result.Err != nil {
+				item = importReportItem{Path: entry.path, Status: importFailed,
+					Reason: "An unexpected error occurred when creating snippet"}
+			}
+			report = append(report, item)
+		}
+	}
+	sort.SliceStable(report, func(i, j int) bool { return report[i].Path < report[j].Path })
+	createResponse(w, http.StatusOK, report)
+}
+
+// groupImportEntries groups the files of an archive into the snippets they are imported as.
+// Every top level directory becomes a snippet with the files in it while every file at the
+// top level becomes a snippet of its own. Directories named after a snippet listed in a
+// manifest.json at the top level, as written by an export, take on its metadata. Files that
+// cannot be imported are reported as skipped or failed
+func (sh SnippetHandler) groupImportEntries(entries []archiveEntry) ([]importGroup, []importReportItem, error) {
+	report := []importReportItem{}
+	manifest := make(map[string]exportedSnippet)
+	byDir := make(map[string][]archiveEntry)
+	var dirs []string
+	var groups []importGroup
+
+	for _, entry := range entries {
+		if entry.path == "manifest.json" {
+			var m exportManifest
+			if err := json.Unmarshal(entry.content, &m); err != nil {
+				return nil, nil, errors.New("Error, manifest.json could not be decoded")
+			}
+			for _, s := range m.Snippets {
+				manifest[s.ID] = s
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.path == "manifest.json" {
+			continue
+		}
+		segments := strings.Split(entry.path, "/")
+		name := segments[len(segments)-1]
+		switch {
+		case strings.HasPrefix(name, ".") || segments[0] == "__MACOSX":
+			report = append(report, importReportItem{Path: entry.path, Status: importSkipped, Reason: "hidden file"})
+		case len(segments) > 2:
+			report = append(report, importReportItem{Path: entry.path, Status: importSkipped,
+				Reason: "files nested more than one directory deep are not supported"})
+		case int64(len(entry.content)) > sh.MaxContentSize:
+			report = append(report, importReportItem{Path: entry.path, Status: importFailed,
+				Reason: "content exceeds the maximum snippet size"})
+		case len(segments) == 1:
+			groups = append(groups, importGroup{entries: []archiveEntry{entry}})
+		default:
+			if _, ok := byDir[segments[0]]; !ok {
+				dirs = append(dirs, segments[0])
+			}
+			byDir[segments[0]] = append(byDir[segments[0]], entry)
+		}
+	}
+
+	for _, dir := range dirs {
+		group := importGroup{entries: byDir[dir]}
+		sort.Slice(group.entries, func(i, j int) bool { return group.entries[i].path < group.entries[j].path })
+		if s, ok := manifest[dir]; ok {
+			group.snippet = s.Snippet
+			// the primary file named by the manifest comes first
+			for i, entry := range group.entries {
+				if path.Base(entry.path) == s.Filename {
+					group.entries[0], group.entries[i] = group.entries[i], group.entries[0]
+					break
+				}
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	valid := groups[:0]
+	for _, group := range groups {
+		if reason := prepareImportGroup(&group); reason != "" {
+			for _, entry := range group.entries {
+				report = append(report, importReportItem{Path: entry.path, Status: importFailed, Reason: reason})
+			}
+			continue
+		}
+		valid = append(valid, group)
+	}
+	return valid, report, nil
+}
+
+// prepareImportGroup fills in the snippet of the given group from its files and validates it,
+// returning the reason it cannot be imported should it be invalid
+func prepareImportGroup(group *importGroup) string {
+	primary := group.entries[0]
+	s := snippets.Snippet{
+		Filename:    path.Base(primary.path),
+		Description: group.snippet.Description,
+		Visibility:  group.snippet.Visibility,
+		Language:    group.snippet.Language,
+		Content:     string(primary.content),
+		Tags:        group.snippet.Tags,
+	}
+	if s.Visibility == "" {
+		s.Visibility = snippets.VisibilityPrivate
+	}
+	if s.Language == "" {
+		s.Language = snippets.DetectLanguage(s.Filename, s.Content)
+	}
+	group.snippet = s
+
+	if err := s.Validate(); err != nil {
+		return err.Error()
+	}
+	for _, entry := range group.entries[1:] {
+		if err := (snippets.SnippetFile{Filename: path.Base(entry.path)}).Validate(); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// readArchive reads every regular file out of a zip or gzip compressed tar archive of the
+// given size, telling them apart by their leading bytes
+func readArchive(r io.ReaderAt, size int64) ([]archiveEntry, error) {
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, errors.New("Error, archive could not be read")
+	}
+
+	var entries []archiveEntry
+	var err error
+	switch {
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		entries, err = readZipArchive(r, size)
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		entries, err = readTarGzArchive(io.NewSectionReader(r, 0, size))
+	default:
+		return nil, errors.New("Error, archive must be either a zip or tar.gz archive")
+	}
+	if err != nil {
+		return nil, errors.New("Error, archive could not be read: " + err.Error())
+	}
+	return entries, nil
+}
+
+// readZipArchive reads every regular file out of a zip archive
+func readZipArchive(r io.ReaderAt, size int64) ([]archiveEntry, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var entries []archiveEntry
+	remaining := int64(maxImportSize)
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		if len(entries) >= maxImportEntries {
+			return nil, errors.New("too many files")
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := readArchiveEntry(rc, &remaining)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{path: cleanArchivePath(f.Name), content: content})
+	}
+	return entries, nil
+}
+
+// readTarGzArchive reads every regular file out of a gzip compressed tar archive
+func readTarGzArchive(r io.Reader) ([]archiveEntry, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	var entries []archiveEntry
+	remaining := int64(maxImportSize)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if len(entries) >= maxImportEntries {
+			return nil, errors.New("too many files")
+		}
+		content, err := readArchiveEntry(tr, &remaining)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{path: cleanArchivePath(header.Name), content: content})
+	}
+}
+
+// readArchiveEntry reads the content of a file in an archive, failing should it take the total
+// size of the files read so far past what remains of maxImportSize
+func readArchiveEntry(r io.Reader, remaining *int64) ([]byte, error) {
+	content, err := ioutil.ReadAll(io.LimitReader(r, *remaining+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > *remaining {
+		return nil, errors.New("files expand past the maximum size of 100 MiB")
+	}
+	*remaining -= int64(len(content))
+	return content, nil
+}
+
+// cleanArchivePath normalizes the path of a file in an archive into a relative slash
+// separated path without any leading ./ or parent directory references
+func cleanArchivePath(name string) string {
+	name = path.Clean("/" + strings.Replace(name, "\\", "/", -1))
+	return strings.TrimPrefix(name, "/")
+}
@@ -11,10 +11,12 @@ import (
 	"github.com/dgrijalva/jwt-go"
 )
 
-// Authenticator implements the http.Authenticator interface
+// Authenticator implements the http.Authenticator interface. UserService is used to tell
+// whether the owner of a token still exists
 type Authenticator struct {
-	SigningKey []byte
-	ExpiryTime time.Duration
+	SigningKey  []byte
+	ExpiryTime  time.Duration
+	UserService snippets.UserService
 }
 
 // GetAuthorizationInfo extracts and returns the authorization information of the owner
@@ -54,28 +56,38 @@ func (a Authenticator) GenerateToken(info snippets.AuthorizationInfo) (string, e
 	return tokenString, nil
 }
 
-// Authenticate verifies the legitimacy and validity of an authentication token
+// Authenticate verifies the legitimacy and validity of an authentication token, which is no
+// longer valid once its owner has been deleted. An error is returned should the token be
+// supplied in an invalid format or should its owner fail to be looked up
 func (a Authenticator) Authenticate(r *http.Request) (bool, error) {
 	tokenString, err := getTokenFromHeader(r)
 	if err != nil {
 		return false, err
 	}
 
-	_, err = jwt.Parse(tokenString, a.keyGetter)
+	token, err := jwt.Parse(tokenString, a.keyGetter)
 	if err != nil { // error occurs when token is invalid
 		return false, nil
 	}
-	return true, nil
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	userID, _ := claims["userId"].(string)
+	user, err := a.UserService.User(userID)
+	if err != nil {
+		return false, err
+	}
+	return user.ID != "", nil
 }
 
 // getTokenFromHeader extracts and returns an authentication token from the request header,
-// else, it returns an error should an invalid token format be supplied
+// else, it returns an error should an invalid token format be supplied. The token scheme used
+// by GitHub API clients is accepted alongside the Bearer scheme
 func getTokenFromHeader(r *http.Request) (string, error) {
 	tokenString := r.Header.Get("Authorization")
 	tokenParts := strings.Split(tokenString, " ")
 
-	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-		return "", errors.New("Invalid token format supplied")
+	if len(tokenParts) != 2 || (tokenParts[0] != "Bearer" && tokenParts[0] != "token") {
+		return "", snippets.ErrInvalidTokenFormat
 	}
 
 	return tokenParts[1], nil
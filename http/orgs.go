@@ -0,0 +1,433 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// OrgHandler is a sub-router that handles requests related to operations on Organizations
+type OrgHandler struct {
+	*mux.Router
+	OrganizationService snippets.OrganizationService
+	UserService         snippets.UserService
+	Authenticator       Authenticator
+}
+
+// membershipRequest represents the request body granting a user a role in an organization
+type membershipRequest struct {
+	Role snippets.OrgRole `json:"role"`
+}
+
+// NewOrgHandler constructs a new OrgHandler given an OrganizationService implementation and
+// a UserService implementation to look up members with
+func NewOrgHandler(os snippets.OrganizationService, us snippets.UserService, auth Authenticator) *OrgHandler {
+	h := &OrgHandler{
+		Router:              mux.NewRouter(),
+		OrganizationService: os,
+		UserService:         us,
+		Authenticator:       auth,
+	}
+
+	verifyUser := verifyRoute(auth)
+
+	h.Handle("/api/v0/orgs", Adapt(http.HandlerFunc(h.handleGetOrgs), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/orgs/{orgID}", Adapt(http.HandlerFunc(h.handleGetOrgByID), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/orgs", Adapt(http.HandlerFunc(h.handleCreateOrg), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/orgs/{orgID}", Adapt(http.HandlerFunc(h.handlePatchOrg), verifyUser)).Methods("PATCH")
+	h.Handle("/api/v0/orgs/{orgID}", Adapt(http.HandlerFunc(h.handleDeleteOrg), verifyUser)).Methods("DELETE")
+
+	h.Handle("/api/v0/orgs/{orgID}/members", Adapt(http.HandlerFunc(h.handleGetMembers), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/orgs/{orgID}/members/{userID}", Adapt(http.HandlerFunc(h.handleSetMember), verifyUser)).Methods("PUT")
+	h.Handle("/api/v0/orgs/{orgID}/members/{userID}", Adapt(http.HandlerFunc(h.handleRemoveMember), verifyUser)).Methods("DELETE")
+
+	h.Handle("/api/v0/orgs/{orgID}/snippets", Adapt(http.HandlerFunc(h.handleGetOrgSnippets), verifyUser)).Methods("GET")
+
+	return h
+}
+
+// handleGetOrgs
+func (oh OrgHandler) handleGetOrgs(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := oh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving organizations"})
+		return
+	}
+
+	orgs, err := oh.OrganizationService.Organizations(userInfo.UserID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving organizations"})
+		return
+	}
+	createResponse(w, http.StatusOK, orgs)
+}
+
+// handleGetOrgByID
+func (oh OrgHandler) handleGetOrgByID(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["orgID"]
+	if _, ok := oh.membership(w, r, orgID); !ok {
+		return
+	}
+
+	org, err := oh.OrganizationService.Organization(orgID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested organization"})
+		return
+	}
+	if org.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested organization is not found"})
+		return
+	}
+	createResponse(w, http.StatusOK, org)
+}
+
+// handleCreateOrg
+func (oh OrgHandler) handleCreateOrg(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := oh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating organization"})
+		return
+	}
+
+	var newOrg snippets.Organization
+	err = json.NewDecoder(r.Body).Decode(&newOrg)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Invalid request body"})
+		return
+	}
+
+	err = newOrg.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !oh.isOrgNameAvailable(w, newOrg.Name, "") {
+		return
+	}
+
+	orgID, err := oh.OrganizationService.CreateOrganization(userInfo.UserID, newOrg)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when creating organization"})
+		return
+	}
+
+	org, err := oh.OrganizationService.Organization(orgID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting created organization"})
+		return
+	}
+	createResponse(w, http.StatusCreated, org)
+}
+
+// handlePatchOrg
+func (oh OrgHandler) handlePatchOrg(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["orgID"]
+	membership, ok := oh.membership(w, r, orgID)
+	if !ok {
+		return
+	}
+	if !membership.Role.CanAdminister() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the admins of this organization are permitted to do this"})
+		return
+	}
+
+	orgToUpdate, err := oh.OrganizationService.Organization(orgID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating organization"})
+		return
+	}
+	if orgToUpdate.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, organization to update is not found"})
+		return
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&orgToUpdate)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"JSON could not be decoded, invalid request format supplied"})
+		return
+	}
+
+	if orgToUpdate.ID != orgID {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"JSON could not be decoded, invalid request format supplied"})
+		return
+	}
+
+	err = orgToUpdate.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if !oh.isOrgNameAvailable(w, orgToUpdate.Name, orgID) {
+		return
+	}
+
+	err = oh.OrganizationService.UpdateOrganization(orgToUpdate)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when updating organization"})
+		return
+	}
+
+	createResponse(w, http.StatusOK, defaultResponse{"Organization is successfully updated"})
+}
+
+// handleDeleteOrg
+func (oh OrgHandler) handleDeleteOrg(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["orgID"]
+	membership, ok := oh.membership(w, r, orgID)
+	if !ok {
+		return
+	}
+	if membership.Role != snippets.OrgRoleOwner {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owners of this organization are permitted to do this"})
+		return
+	}
+
+	err := oh.OrganizationService.DeleteOrganization(orgID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting organization"})
+		return
+	}
+
+	createResponse(w, http.StatusOK, defaultResponse{"Organization is successfully deleted"})
+}
+
+// handleGetMembers
+func (oh OrgHandler) handleGetMembers(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["orgID"]
+	if _, ok := oh.membership(w, r, orgID); !ok {
+		return
+	}
+
+	memberships, err := oh.OrganizationService.Memberships(orgID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving members"})
+		return
+	}
+	createResponse(w, http.StatusOK, memberships)
+}
+
+// handleSetMember
+func (oh OrgHandler) handleSetMember(w http.ResponseWriter, r *http.Request) {
+	orgID, userID := mux.Vars(r)["orgID"], mux.Vars(r)["userID"]
+	membership, ok := oh.membership(w, r, orgID)
+	if !ok {
+		return
+	}
+	if !membership.Role.CanAdminister() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the admins of this organization are permitted to do this"})
+		return
+	}
+
+	var req membershipRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&req)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"JSON could not be decoded, invalid request format supplied"})
+		return
+	}
+
+	newMembership := snippets.Membership{OrgID: orgID, UserID: userID, Role: req.Role}
+	err = newMembership.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := oh.UserService.User(userID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when setting member"})
+		return
+	}
+	if user == (snippets.User{}) {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested user is not found"})
+		return
+	}
+
+	existing, err := oh.OrganizationService.Membership(orgID, userID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when setting member"})
+		return
+	}
+	// only owners may make or unmake other owners
+	if (newMembership.Role == snippets.OrgRoleOwner || existing.Role == snippets.OrgRoleOwner) &&
+		membership.Role != snippets.OrgRoleOwner {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owners of this organization are permitted to do this"})
+		return
+	}
+	if existing.Role == snippets.OrgRoleOwner && newMembership.Role != snippets.OrgRoleOwner && !oh.hasOtherOwner(w, orgID, userID) {
+		return
+	}
+
+	err = oh.OrganizationService.SetMembership(newMembership)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when setting member"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Member is successfully set"})
+}
+
+// handleRemoveMember
+func (oh OrgHandler) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	orgID, userID := mux.Vars(r)["orgID"], mux.Vars(r)["userID"]
+	membership, ok := oh.membership(w, r, orgID)
+	if !ok {
+		return
+	}
+
+	existing, err := oh.OrganizationService.Membership(orgID, userID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when removing member"})
+		return
+	}
+	if existing.UserID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, member to remove is not found"})
+		return
+	}
+	// members are free to leave an organization on their own
+	if existing.UserID != membership.UserID && (!membership.Role.CanAdminister() ||
+		(existing.Role == snippets.OrgRoleOwner && membership.Role != snippets.OrgRoleOwner)) {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, you are not permitted to remove this member"})
+		return
+	}
+	if existing.Role == snippets.OrgRoleOwner && !oh.hasOtherOwner(w, orgID, userID) {
+		return
+	}
+
+	err = oh.OrganizationService.RemoveMembership(orgID, userID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when removing member"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Member is successfully removed"})
+}
+
+// handleGetOrgSnippets
+func (oh OrgHandler) handleGetOrgSnippets(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["orgID"]
+	if _, ok := oh.membership(w, r, orgID); !ok {
+		return
+	}
+
+	opts, err := parseListOptions(r, "filename", "created", "updated")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+	updatedSince, err := parseTimeParam(r, "updated_since")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+
+	filter := snippets.SnippetFilter{
+		Tags:         r.URL.Query()["tag"],
+		Visibility:   snippets.Visibility(r.URL.Query().Get("visibility")),
+		Language:     r.URL.Query().Get("language"),
+		UpdatedSince: updatedSince,
+	}
+
+	snippets, page, err := oh.OrganizationService.OrganizationSnippets(orgID, filter, opts)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving snippets"})
+		return
+	}
+	setPageHeaders(w, r, page)
+	createResponse(w, http.StatusOK, snippets)
+}
+
+// membership returns the membership of the user making a request in the organization with
+// the given orgID. An organization is not revealed to those outside of it, so a response is
+// written and false is returned as though it is not found should the user not be a member
+func (oh OrgHandler) membership(w http.ResponseWriter, r *http.Request, orgID string) (snippets.Membership, bool) {
+	userInfo, err := oh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested organization"})
+		return snippets.Membership{}, false
+	}
+
+	membership, err := oh.OrganizationService.Membership(orgID, userInfo.UserID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested organization"})
+		return membership, false
+	}
+	if membership.UserID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested organization is not found"})
+		return membership, false
+	}
+	return membership, true
+}
+
+// isOrgNameAvailable reports whether the given name is not taken by an organization other
+// than the one with the given orgID. A response is written should it be taken
+func (oh OrgHandler) isOrgNameAvailable(w http.ResponseWriter, name string, orgID string) bool {
+	org, err := oh.OrganizationService.OrganizationByName(name)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when checking organization name"})
+		return false
+	}
+	if org.ID != "" && org.ID != orgID {
+		createResponse(w, http.StatusConflict, defaultResponse{
+			"Error, an organization with the same name already exists"})
+		return false
+	}
+	return true
+}
+
+// hasOtherOwner reports whether the organization with the given orgID has an owner other
+// than the user with the given userID, so that it is never left without one. Owners who have
+// been deleted are not counted as they are left out of the memberships. A response is
+// written should it not
+func (oh OrgHandler) hasOtherOwner(w http.ResponseWriter, orgID string, userID string) bool {
+	memberships, err := oh.OrganizationService.Memberships(orgID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when checking organization owners"})
+		return false
+	}
+	for _, m := range memberships {
+		if m.Role == snippets.OrgRoleOwner && m.UserID != userID {
+			return true
+		}
+	}
+	createResponse(w, http.StatusConflict, defaultResponse{
+		"Error, an organization has to be left with at least one owner"})
+	return false
+}
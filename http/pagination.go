@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/go-ozzo/ozzo-validation/is"
+)
+
+// The number of resources listed in a page by default and at most
+const (
+	defaultPageLimit = 30
+	maxPageLimit     = 100
+)
+
+// timeSorts holds the sort keys whose cursors hold a timestamp as their sort value
+var timeSorts = map[string]bool{"created": true, "updated": true}
+
+// parseListOptions reads the limit, sort, order and cursor query parameters of a request that
+// lists resources. Only the given sort keys are accepted, the first of which is the default
+func parseListOptions(r *http.Request, sorts ...string) (snippets.ListOptions, error) {
+	query := r.URL.Query()
+	opts := snippets.ListOptions{Limit: defaultPageLimit, Sort: sorts[0]}
+
+	if limit := query.Get("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > maxPageLimit {
+			return opts, errors.New("Error, limit must be an integer from 1 to " + strconv.Itoa(maxPageLimit))
+		}
+		opts.Limit = l
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		supported := false
+		for _, s := range sorts {
+			supported = supported || s == sort
+		}
+		if !supported {
+			return opts, errors.New("Error, sort must be one of " + strings.Join(sorts, ", "))
+		}
+		opts.Sort = sort
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Descending = true
+	default:
+		return opts, errors.New("Error, order must be either asc or desc")
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil {
+			return opts, errors.New("Error, invalid cursor supplied")
+		}
+		if c.Sort != opts.Sort {
+			return opts, errors.New("Error, cursor was not issued for sort " + opts.Sort)
+		}
+		opts.Cursor = &c
+	}
+
+	return opts, nil
+}
+
+// parseTimeParam reads an RFC 3339 timestamp from the query parameter with the given name,
+// returning a zero time.Time should the parameter not be supplied
+func parseTimeParam(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, errors.New("Error, " + name + " must be an RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
+// setPageHeaders sets the Link header of a response listing a page of resources to the URLs
+// of the pages before and after it, should there be any, with their cursors also set in the
+// X-Prev-Cursor and X-Next-Cursor headers
+func setPageHeaders(w http.ResponseWriter, r *http.Request, page snippets.Page) {
+	var links []string
+	for _, p := range []struct {
+		rel    string
+		header string
+		cursor *snippets.Cursor
+	}{{"prev", "X-Prev-Cursor", page.Prev}, {"next", "X-Next-Cursor", page.Next}} {
+		if p.cursor == nil {
+			continue
+		}
+		cursor := encodeCursor(*p.cursor)
+		query := r.URL.Query()
+		query.Set("cursor", cursor)
+		links = append(links, "<"+r.URL.Path+"?"+query.Encode()+`>; rel="`+p.rel+`"`)
+		w.Header().Set(p.header, cursor)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// encodeCursor converts a cursor into an opaque string that is safe to use in URLs
+func encodeCursor(c snippets.Cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor converts a string produced by encodeCursor back into a cursor, checking that
+// its ID and sort value are of the form the resources it points to have
+func decodeCursor(s string) (snippets.Cursor, error) {
+	var c snippets.Cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err = json.Unmarshal(b, &c); err != nil {
+		return c, err
+	}
+	if c.ID == "" || is.UUID.Validate(c.ID) != nil {
+		return c, errors.New("cursor has an invalid ID")
+	}
+	if _, err = time.Parse(time.RFC3339Nano, c.Value); timeSorts[c.Sort] && err != nil {
+		return c, errors.New("cursor has an invalid timestamp")
+	}
+	return c, nil
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// handleGetRawSnippet
+func (sh SnippetHandler) handleGetRawSnippet(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "getting requested snippet") {
+		return
+	}
+	serveRawFile(w, r, snippets.SnippetFile{SnippetID: snippet.ID, Filename: snippet.Filename, Content: snippet.Content})
+}
+
+// handleGetRawSnippetFile
+func (sh SnippetHandler) handleGetRawSnippetFile(w http.ResponseWriter, r *http.Request) {
+	snippetID, fileName := mux.Vars(r)["snippetID"], mux.Vars(r)["fileName"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet file"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	sh.serveRawSnippetFile(w, r, snippet, fileName)
+}
+
+// handleGetSharedRawSnippet
+func (sh SnippetHandler) handleGetSharedRawSnippet(w http.ResponseWriter, r *http.Request) {
+	shareToken := mux.Vars(r)["shareToken"]
+	snippet, err := sh.SnippetService.SharedSnippet(shareToken)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "getting requested snippet") {
+		return
+	}
+	serveRawFile(w, r, snippets.SnippetFile{SnippetID: snippet.ID, Filename: snippet.Filename, Content: snippet.Content})
+}
+
+// handleGetSharedRawSnippetFile
+func (sh SnippetHandler) handleGetSharedRawSnippetFile(w http.ResponseWriter, r *http.Request) {
+	shareToken, fileName := mux.Vars(r)["shareToken"], mux.Vars(r)["fileName"]
+	snippet, err := sh.SnippetService.SharedSnippet(shareToken)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet file"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	sh.serveRawSnippetFile(w, r, snippet, fileName)
+}
+
+// serveRawSnippetFile looks up the file with the given filename of the given snippet and
+// serves its content as is
+func (sh SnippetHandler) serveRawSnippetFile(w http.ResponseWriter, r *http.Request, snippet snippets.Snippet, fileName string) {
+	file, err := sh.SnippetService.SnippetFile(snippet.ID, fileName)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet file"})
+		return
+	}
+	if file == (snippets.SnippetFile{}) {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet file is not found"})
+		return
+	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "getting requested snippet file") {
+		return
+	}
+	serveRawFile(w, r, file)
+}
+
+// serveRawFile writes the content of a file as the response body with a content type derived
+// from its filename, as an attachment named after it. The content's hash is used as an ETag
+// so that conditional and range requests are handled by http.ServeContent
+func serveRawFile(w http.ResponseWriter, r *http.Request, file snippets.SnippetFile) {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	hash := sha256.Sum256([]byte(file.Content))
+
+	w.Header().Set("Content-Type", rawContentType(file.Filename))
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(hash[:])+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, file.Filename, time.Time{}, strings.NewReader(file.Content))
+}
+
+// rawContentType returns the content type that a file with the given filename is served as.
+// Types that a browser could run as a page or script, and those of unknown extensions, are
+// served as plain text instead
+func rawContentType(filename string) string {
+	contentType := mime.TypeByExtension(path.Ext(filename))
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "text/plain; charset=utf-8"
+	}
+	switch {
+	case mediaType == "text/html", mediaType == "image/svg+xml",
+		strings.Contains(mediaType, "javascript"), strings.HasSuffix(mediaType, "xml"):
+		return "text/plain; charset=utf-8"
+	}
+	return contentType
+}
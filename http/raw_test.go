@@ -0,0 +1,27 @@
+package http
+
+import "testing"
+
+func TestRawContentType(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"style.css", "text/css; charset=utf-8"},
+		{"logo.png", "image/png"},
+		{"data.json", "application/json"},
+		{"index.html", "text/plain; charset=utf-8"},
+		{"INDEX.HTM", "text/plain; charset=utf-8"},
+		{"icon.svg", "text/plain; charset=utf-8"},
+		{"app.js", "text/plain; charset=utf-8"},
+		{"module.mjs", "text/plain; charset=utf-8"},
+		{"feed.xml", "text/plain; charset=utf-8"},
+		{"file.unknownextension", "text/plain; charset=utf-8"},
+		{"Makefile", "text/plain; charset=utf-8"},
+	}
+	for _, test := range tests {
+		if got := rawContentType(test.filename); got != test.want {
+			t.Errorf("rawContentType(%q) = %q, want %q", test.filename, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"html"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/gorilla/mux"
+)
+
+// defaultTheme is the theme snippets are rendered with should none be requested
+const defaultTheme = "github"
+
+// handleRenderSnippet
+func (sh SnippetHandler) handleRenderSnippet(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when rendering snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	sh.renderSnippet(w, r, snippet)
+}
+
+// handleRenderSharedSnippet
+func (sh SnippetHandler) handleRenderSharedSnippet(w http.ResponseWriter, r *http.Request) {
+	shareToken := mux.Vars(r)["shareToken"]
+	snippet, err := sh.SnippetService.SharedSnippet(shareToken)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when rendering snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	sh.renderSnippet(w, r, snippet)
+}
+
+// renderSnippet writes every file of the given snippet as a syntax highlighted HTML document,
+// using the theme and line numbers requested through the theme and line_numbers parameters
+func (sh SnippetHandler) renderSnippet(w http.ResponseWriter, r *http.Request, snippet snippets.Snippet) {
+	opts, err := sh.parseHighlightOptions(r)
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "rendering snippet") {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>` + html.EscapeString(snippet.Filename) + `</title></head><body>`)
+	err = sh.highlightSnippet(&buf, snippet, opts)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when rendering snippet"})
+		return
+	}
+	buf.WriteString(`</body></html>`)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// parseHighlightOptions reads the theme and line_numbers parameters of a request into
+// snippets.HighlightOptions, falling back to defaultTheme and no line numbers
+func (sh SnippetHandler) parseHighlightOptions(r *http.Request) (snippets.HighlightOptions, error) {
+	opts := snippets.HighlightOptions{Theme: r.URL.Query().Get("theme")}
+	if opts.Theme == "" {
+		opts.Theme = defaultTheme
+	}
+	if !sh.Highlighter.HasTheme(opts.Theme) {
+		return opts, errors.New("Error, requested theme is not supported")
+	}
+	if lineNumbers := r.URL.Query().Get("line_numbers"); lineNumbers != "" {
+		var err error
+		opts.LineNumbers, err = strconv.ParseBool(lineNumbers)
+		if err != nil {
+			return opts, errors.New("Error, line_numbers must be either true or false")
+		}
+	}
+	return opts, nil
+}
+
+// highlightSnippet writes every file of the given snippet to w as a section holding its
+// filename and its syntax highlighted content
+func (sh SnippetHandler) highlightSnippet(w io.Writer, snippet snippets.Snippet, opts snippets.HighlightOptions) error {
+	files, err := sh.SnippetService.SnippetFiles(snippet.ID)
+	if err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		// the language of the snippet describes its primary file only
+		language := snippet.Language
+		if i > 0 {
+			language = snippets.DetectLanguage(file.Filename, file.Content)
+		}
+
+		io.WriteString(w, `<section><h2>`+html.EscapeString(file.Filename)+`</h2>`)
+		err = sh.Highlighter.Highlight(w, file.Content, language, opts)
+		if err != nil {
+			return err
+		}
+		io.WriteString(w, `</section>`)
+	}
+	return nil
+}
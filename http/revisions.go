@@ -0,0 +1,213 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chuabingquan/snippets/diff"
+	"github.com/gorilla/mux"
+)
+
+// handleGetRevisions
+func (sh SnippetHandler) handleGetRevisions(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving revisions"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving revisions"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	revisions, err := sh.SnippetService.Revisions(snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving revisions"})
+		return
+	}
+	createResponse(w, http.StatusOK, revisions)
+}
+
+// handleGetRevisionByID
+func (sh SnippetHandler) handleGetRevisionByID(w http.ResponseWriter, r *http.Request) {
+	snippetID, revisionID := mux.Vars(r)["snippetID"], mux.Vars(r)["revisionID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested revision"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested revision"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	revision, err := sh.SnippetService.Revision(snippetID, revisionID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested revision"})
+		return
+	}
+	if revision.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested revision is not found"})
+		return
+	}
+	createResponse(w, http.StatusOK, revision)
+}
+
+// handleRestoreRevision
+func (sh SnippetHandler) handleRestoreRevision(w http.ResponseWriter, r *http.Request) {
+	snippetID, revisionID := mux.Vars(r)["snippetID"], mux.Vars(r)["revisionID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when restoring revision"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when restoring revision"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !snippet.Role.CanEdit() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, you are not permitted to edit this snippet"})
+		return
+	}
+
+	revision, err := sh.SnippetService.Revision(snippetID, revisionID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when restoring revision"})
+		return
+	}
+	if revision.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, revision to restore is not found"})
+		return
+	}
+
+	err = sh.SnippetService.RestoreRevision(userInfo.UserID, snippetID, revisionID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when restoring revision"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Snippet is successfully restored to the given revision"})
+}
+
+// handleGetDiff
+func (sh SnippetHandler) handleGetDiff(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	query := r.URL.Query()
+	fromID, toID := query.Get("from"), query.Get("to")
+
+	context := diff.DefaultContext
+	if c := query.Get("context"); c != "" {
+		var err error
+		context, err = strconv.Atoi(c)
+		if err != nil || context < 0 {
+			createResponse(w, http.StatusBadRequest, defaultResponse{
+				"Error, context must be a non-negative integer"})
+			return
+		}
+	}
+	if fromID == "" {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, the revision to diff from must be supplied"})
+		return
+	}
+
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when computing diff"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when computing diff"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	// diff against the latest revision when no revision to diff to is supplied
+	if toID == "" {
+		revisions, err := sh.SnippetService.Revisions(snippetID)
+		if err != nil {
+			createResponse(w, http.StatusInternalServerError, defaultResponse{
+				"An unexpected error occurred when computing diff"})
+			return
+		}
+		if len(revisions) > 0 {
+			toID = revisions[0].ID
+		}
+	}
+
+	from, err := sh.SnippetService.Revision(snippetID, fromID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when computing diff"})
+		return
+	}
+	to, err := sh.SnippetService.Revision(snippetID, toID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when computing diff"})
+		return
+	}
+	if from.ID == "" || to.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested revision is not found"})
+		return
+	}
+
+	files := diff.Revisions(from, to, context)
+
+	if query.Get("format") == "unified" || strings.Contains(r.Header.Get("Accept"), "text/x-diff") {
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		diff.WriteUnified(w, files)
+		return
+	}
+
+	createResponse(w, http.StatusOK, struct {
+		From  string      `json:"from"`
+		To    string      `json:"to"`
+		Files []diff.File `json:"files"`
+	}{from.ID, to.ID, files})
+}
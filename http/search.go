@@ -0,0 +1,26 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+// handleSearchSnippets
+func (sh SnippetHandler) handleSearchSnippets(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, a search query must be supplied"})
+		return
+	}
+
+	// anonymous users only search public snippets
+	userInfo, _ := sh.Authenticator.GetAuthorizationInfo(r)
+	results, err := sh.SnippetService.Search(userInfo.UserID, query)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when searching snippets"})
+		return
+	}
+	createResponse(w, http.StatusOK, results)
+}
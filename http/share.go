@@ -0,0 +1,162 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// shareTokenResponse represents the response body carrying a snippet's share token
+type shareTokenResponse struct {
+	ShareToken string `json:"shareToken"`
+}
+
+// handleGetShareToken
+func (sh SnippetHandler) handleGetShareToken(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting share token"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting share token"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !snippet.Role.CanManage() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owner of this snippet is permitted to do this"})
+		return
+	}
+	if snippet.ShareToken == nil {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet has no share token"})
+		return
+	}
+	createResponse(w, http.StatusOK, shareTokenResponse{*snippet.ShareToken})
+}
+
+// handleGenerateShareToken
+func (sh SnippetHandler) handleGenerateShareToken(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when generating share token"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when generating share token"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !snippet.Role.CanManage() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owner of this snippet is permitted to do this"})
+		return
+	}
+
+	shareToken, err := sh.SnippetService.GenerateShareToken(snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when generating share token"})
+		return
+	}
+	createResponse(w, http.StatusCreated, shareTokenResponse{shareToken})
+}
+
+// handleRevokeShareToken
+func (sh SnippetHandler) handleRevokeShareToken(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when revoking share token"})
+		return
+	}
+
+	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when revoking share token"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !snippet.Role.CanManage() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owner of this snippet is permitted to do this"})
+		return
+	}
+
+	err = sh.SnippetService.RevokeShareToken(snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when revoking share token"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Share token is successfully revoked"})
+}
+
+// handleGetSharedSnippet
+func (sh SnippetHandler) handleGetSharedSnippet(w http.ResponseWriter, r *http.Request) {
+	shareToken := mux.Vars(r)["shareToken"]
+	snippet, err := sh.SnippetService.SharedSnippet(shareToken)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when getting requested snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "getting requested snippet") {
+		return
+	}
+	createResponse(w, http.StatusOK, snippet)
+}
+
+// handleGetSharedSnippetFiles
+func (sh SnippetHandler) handleGetSharedSnippetFiles(w http.ResponseWriter, r *http.Request) {
+	shareToken := mux.Vars(r)["shareToken"]
+	snippet, err := sh.SnippetService.SharedSnippet(shareToken)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving snippet files"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested snippet is not found"})
+		return
+	}
+
+	files, err := sh.SnippetService.SnippetFiles(snippet.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving snippet files"})
+		return
+	}
+	createResponse(w, http.StatusOK, withoutContent(snippet, files))
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/chuabingquan/snippets"
 	"github.com/gorilla/mux"
@@ -11,25 +12,96 @@ import (
 // SnippetHandler is a sub-router that handles requests related to operations on Snippets
 type SnippetHandler struct {
 	*mux.Router
-	SnippetService snippets.SnippetService
-	Authenticator  Authenticator
+	SnippetService      snippets.SnippetService
+	UserService         snippets.UserService
+	OrganizationService snippets.OrganizationService
+	Authenticator       Authenticator
+	Highlighter         snippets.Highlighter
+	MaxContentSize      int64
+	BaseURL             string
 }
 
-// NewSnippetHandler constructs a new SnippetHandler given a SnippetService implementation
-func NewSnippetHandler(ss snippets.SnippetService, auth Authenticator) *SnippetHandler {
+// NewSnippetHandler constructs a new SnippetHandler given a SnippetService implementation,
+// a UserService implementation to look up collaborators with, an OrganizationService
+// implementation to check the organizations snippets are created for, a Highlighter
+// implementation, the maximum size in bytes of a snippet's content and the URL the API is
+// publicly reachable at, which links to snippets are built from
+func NewSnippetHandler(ss snippets.SnippetService, us snippets.UserService, os snippets.OrganizationService, auth Authenticator,
+	hl snippets.Highlighter, maxContentSize int64, baseURL string) *SnippetHandler {
 	h := &SnippetHandler{
-		Router:         mux.NewRouter(),
-		SnippetService: ss,
-		Authenticator:  auth,
+		Router:              mux.NewRouter(),
+		SnippetService:      ss,
+		UserService:         us,
+		OrganizationService: os,
+		Authenticator:       auth,
+		Highlighter:         hl,
+		MaxContentSize:      maxContentSize,
+		BaseURL:             baseURL,
 	}
 
 	verifyUser := verifyRoute(auth)
+	identifyUser := identifyRoute(auth)
+
+	h.Handle("/api/v0/oembed", Adapt(http.HandlerFunc(h.handleGetOEmbed))).Methods("GET")
 
 	h.Handle("/api/v0/snippets", Adapt(http.HandlerFunc(h.handleGetSnippets), verifyUser)).Methods("GET")
-	h.Handle("/api/v0/snippets/{snippetID}", Adapt(http.HandlerFunc(h.handleGetSnippetByID), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/public", Adapt(http.HandlerFunc(h.handleGetPublicSnippets))).Methods("GET")
+	h.Handle("/api/v0/snippets/starred", Adapt(http.HandlerFunc(h.handleGetStarredSnippets), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/tags", Adapt(http.HandlerFunc(h.handleGetTags), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/trash", Adapt(http.HandlerFunc(h.handleGetTrashedSnippets), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/search", Adapt(http.HandlerFunc(h.handleSearchSnippets), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/export", Adapt(http.HandlerFunc(h.handleExportSnippets), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/import", Adapt(http.HandlerFunc(h.handleImportSnippets), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/snippets/shared/{shareToken}", Adapt(http.HandlerFunc(h.handleGetSharedSnippet))).Methods("GET")
+	h.Handle("/api/v0/snippets/shared/{shareToken}/files", Adapt(http.HandlerFunc(h.handleGetSharedSnippetFiles))).Methods("GET")
+	h.Handle("/api/v0/snippets/shared/{shareToken}/raw", Adapt(http.HandlerFunc(h.handleGetSharedRawSnippet))).Methods("GET")
+	h.Handle("/api/v0/snippets/shared/{shareToken}/files/{fileName}/raw", Adapt(http.HandlerFunc(h.handleGetSharedRawSnippetFile))).Methods("GET")
+	h.Handle("/api/v0/snippets/shared/{shareToken}/render", Adapt(http.HandlerFunc(h.handleRenderSharedSnippet))).Methods("GET")
+	h.Handle("/api/v0/snippets/shared/{shareToken}/embed", Adapt(http.HandlerFunc(h.handleGetEmbed))).Methods("GET")
+	h.Handle("/api/v0/snippets/shared/{shareToken}/embed.js", Adapt(http.HandlerFunc(h.handleGetEmbedScript))).Methods("GET")
+	h.Handle("/api/v0/snippets/shared/{shareToken}/fork", Adapt(http.HandlerFunc(h.handleForkSharedSnippet), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/snippets/{snippetID}", Adapt(http.HandlerFunc(h.handleGetSnippetByID), identifyUser)).Methods("GET")
 	h.Handle("/api/v0/snippets", Adapt(http.HandlerFunc(h.handleCreateSnippet), verifyUser)).Methods("POST")
 	h.Handle("/api/v0/snippets/{snippetID}", Adapt(http.HandlerFunc(h.handlePatchSnippet), verifyUser)).Methods("PATCH")
 	h.Handle("/api/v0/snippets/{snippetID}", Adapt(http.HandlerFunc(h.handleDeleteSnippet), verifyUser)).Methods("DELETE")
+	h.Handle("/api/v0/snippets/{snippetID}/restore", Adapt(http.HandlerFunc(h.handleRestoreSnippet), verifyUser)).Methods("POST")
+
+	h.Handle("/api/v0/snippets/{snippetID}/files", Adapt(http.HandlerFunc(h.handleGetSnippetFiles), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/files/{fileName}", Adapt(http.HandlerFunc(h.handleGetSnippetFile), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/files", Adapt(http.HandlerFunc(h.handleAddSnippetFile), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/snippets/{snippetID}/files/{fileName}", Adapt(http.HandlerFunc(h.handlePatchSnippetFile), verifyUser)).Methods("PATCH")
+	h.Handle("/api/v0/snippets/{snippetID}/files/{fileName}", Adapt(http.HandlerFunc(h.handleDeleteSnippetFile), verifyUser)).Methods("DELETE")
+
+	h.Handle("/api/v0/snippets/{snippetID}/raw", Adapt(http.HandlerFunc(h.handleGetRawSnippet), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/files/{fileName}/raw", Adapt(http.HandlerFunc(h.handleGetRawSnippetFile), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/render", Adapt(http.HandlerFunc(h.handleRenderSnippet), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/embed", Adapt(http.HandlerFunc(h.handleGetEmbed))).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/embed.js", Adapt(http.HandlerFunc(h.handleGetEmbedScript))).Methods("GET")
+
+	h.Handle("/api/v0/snippets/{snippetID}/revisions", Adapt(http.HandlerFunc(h.handleGetRevisions), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/revisions/{revisionID}", Adapt(http.HandlerFunc(h.handleGetRevisionByID), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/revisions/{revisionID}/restore", Adapt(http.HandlerFunc(h.handleRestoreRevision), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/snippets/{snippetID}/diff", Adapt(http.HandlerFunc(h.handleGetDiff), verifyUser)).Methods("GET")
+
+	h.Handle("/api/v0/snippets/{snippetID}/comments", Adapt(http.HandlerFunc(h.handleGetComments), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/comments/{commentID}", Adapt(http.HandlerFunc(h.handleGetCommentByID), identifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/comments", Adapt(http.HandlerFunc(h.handleCreateComment), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/snippets/{snippetID}/comments/{commentID}", Adapt(http.HandlerFunc(h.handlePatchComment), verifyUser)).Methods("PATCH")
+	h.Handle("/api/v0/snippets/{snippetID}/comments/{commentID}", Adapt(http.HandlerFunc(h.handleDeleteComment), verifyUser)).Methods("DELETE")
+
+	h.Handle("/api/v0/snippets/{snippetID}/fork", Adapt(http.HandlerFunc(h.handleForkSnippet), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/snippets/{snippetID}/forks", Adapt(http.HandlerFunc(h.handleGetForks), identifyUser)).Methods("GET")
+
+	h.Handle("/api/v0/snippets/{snippetID}/star", Adapt(http.HandlerFunc(h.handleStarSnippet), verifyUser)).Methods("PUT")
+	h.Handle("/api/v0/snippets/{snippetID}/star", Adapt(http.HandlerFunc(h.handleUnstarSnippet), verifyUser)).Methods("DELETE")
+
+	h.Handle("/api/v0/snippets/{snippetID}/share", Adapt(http.HandlerFunc(h.handleGetShareToken), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/share", Adapt(http.HandlerFunc(h.handleGenerateShareToken), verifyUser)).Methods("POST")
+	h.Handle("/api/v0/snippets/{snippetID}/share", Adapt(http.HandlerFunc(h.handleRevokeShareToken), verifyUser)).Methods("DELETE")
+
+	h.Handle("/api/v0/snippets/{snippetID}/collaborators", Adapt(http.HandlerFunc(h.handleGetCollaborators), verifyUser)).Methods("GET")
+	h.Handle("/api/v0/snippets/{snippetID}/collaborators/{userID}", Adapt(http.HandlerFunc(h.handleSetCollaborator), verifyUser)).Methods("PUT")
+	h.Handle("/api/v0/snippets/{snippetID}/collaborators/{userID}", Adapt(http.HandlerFunc(h.handleRemoveCollaborator), verifyUser)).Methods("DELETE")
 
 	return h
 }
@@ -43,36 +115,84 @@ func (sh SnippetHandler) handleGetSnippets(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	snippets, err := sh.SnippetService.Snippets(userInfo.UserID)
+	opts, err := parseListOptions(r, "filename", "created", "updated")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+	updatedSince, err := parseTimeParam(r, "updated_since")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+
+	filter := snippets.SnippetFilter{
+		Tags:         r.URL.Query()["tag"],
+		Visibility:   snippets.Visibility(r.URL.Query().Get("visibility")),
+		Language:     r.URL.Query().Get("language"),
+		UpdatedSince: updatedSince,
+	}
+
+	snippets, page, err := sh.SnippetService.Snippets(userInfo.UserID, filter, opts)
 	if err != nil {
 		createResponse(w, http.StatusInternalServerError, defaultResponse{
 			"An unexpected error occurred when retrieving snippets"})
 		return
 	}
+	setPageHeaders(w, r, page)
 	createResponse(w, http.StatusOK, snippets)
 }
 
-// handleGetSnippetByID
-func (sh SnippetHandler) handleGetSnippetByID(w http.ResponseWriter, r *http.Request) {
-	snippetID := mux.Vars(r)["snippetID"]
-	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+// handleGetPublicSnippets
+func (sh SnippetHandler) handleGetPublicSnippets(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r, "created", "updated", "filename")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+	// the feed lists the most recent snippets first by default
+	if r.URL.Query().Get("order") == "" {
+		opts.Descending = true
+	}
+	updatedSince, err := parseTimeParam(r, "updated_since")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+
+	filter := snippets.SnippetFilter{
+		Tags:         r.URL.Query()["tag"],
+		Language:     r.URL.Query().Get("language"),
+		UpdatedSince: updatedSince,
+	}
+
+	snippets, page, err := sh.SnippetService.PublicSnippets(filter, opts)
 	if err != nil {
 		createResponse(w, http.StatusInternalServerError, defaultResponse{
-			"An unexpected error occurred when getting requested snippet"})
+			"An unexpected error occurred when retrieving snippets"})
 		return
 	}
+	setPageHeaders(w, r, page)
+	createResponse(w, http.StatusOK, snippets)
+}
 
-	snippet, err := sh.SnippetService.Snippet(userInfo.UserID, snippetID)
+// handleGetSnippetByID
+func (sh SnippetHandler) handleGetSnippetByID(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
 	if err != nil {
 		createResponse(w, http.StatusInternalServerError, defaultResponse{
 			"An unexpected error occurred when getting requested snippet"})
 		return
 	}
-	if snippet == (snippets.Snippet{}) {
+	if snippet.ID == "" {
 		createResponse(w, http.StatusNotFound, defaultResponse{
 			"Error, requested snippet is not found"})
 		return
 	}
+	if !readSnippet(w, r, sh.SnippetService, snippet, "snippet", "getting requested snippet") {
+		return
+	}
 	createResponse(w, http.StatusOK, snippet)
 }
 
@@ -86,7 +206,12 @@ func (sh SnippetHandler) handleCreateSnippet(w http.ResponseWriter, r *http.Requ
 	}
 
 	var newSnippet snippets.Snippet
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize(sh.MaxContentSize))
 	err = json.NewDecoder(r.Body).Decode(&newSnippet)
+	if isRequestBodyTooLarge(err) || int64(len(newSnippet.Content)) > sh.MaxContentSize {
+		respondContentTooLarge(w, sh.MaxContentSize)
+		return
+	}
 	if err != nil {
 		createResponse(w, http.StatusBadRequest, defaultResponse{
 			"Invalid request body"})
@@ -94,8 +219,35 @@ func (sh SnippetHandler) handleCreateSnippet(w http.ResponseWriter, r *http.Requ
 	}
 
 	newSnippet.Owner = userInfo.UserID
+	if newSnippet.Visibility == "" {
+		newSnippet.Visibility = snippets.VisibilityPrivate
+	}
+	if newSnippet.Language == "" {
+		newSnippet.Language = snippets.DetectLanguage(newSnippet.Filename, newSnippet.Content)
+	}
 
-	err = sh.SnippetService.CreateSnippet(newSnippet)
+	err = newSnippet.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// every member of an organization is permitted to create snippets for it
+	if newSnippet.OrgID != nil {
+		membership, err := sh.OrganizationService.Membership(*newSnippet.OrgID, userInfo.UserID)
+		if err != nil {
+			createResponse(w, http.StatusInternalServerError, defaultResponse{
+				"An unexpected error occurred when creating snippet"})
+			return
+		}
+		if membership.UserID == "" {
+			createResponse(w, http.StatusNotFound, defaultResponse{
+				"Error, requested organization is not found"})
+			return
+		}
+	}
+
+	_, err = sh.SnippetService.CreateSnippet(newSnippet, nil)
 	if err != nil {
 		createResponse(w, http.StatusInternalServerError, defaultResponse{
 			"An unexpected error occurred when creating snippet"})
@@ -120,15 +272,28 @@ func (sh SnippetHandler) handlePatchSnippet(w http.ResponseWriter, r *http.Reque
 			"An unexpected error occurred when getting requested snippet"})
 		return
 	}
-	if snippetToUpdate == (snippets.Snippet{}) {
+	if snippetToUpdate.ID == "" {
 		createResponse(w, http.StatusNotFound, defaultResponse{
 			"Error, snippet to update is not found"})
 		return
 	}
+	if !snippetToUpdate.Role.CanEdit() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, you are not permitted to edit this snippet"})
+		return
+	}
 
+	original := snippetToUpdate
+	filename, content, language := snippetToUpdate.Filename, snippetToUpdate.Content, snippetToUpdate.Language
+	snippetToUpdate.Language = ""
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize(sh.MaxContentSize))
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 	err = dec.Decode(&snippetToUpdate)
+	if isRequestBodyTooLarge(err) || int64(len(snippetToUpdate.Content)) > sh.MaxContentSize {
+		respondContentTooLarge(w, sh.MaxContentSize)
+		return
+	}
 	if err != nil {
 		createResponse(w, http.StatusBadRequest, defaultResponse{
 			"JSON could not be decoded, invalid request format supplied"})
@@ -141,7 +306,49 @@ func (sh SnippetHandler) handlePatchSnippet(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = sh.SnippetService.UpdateSnippet(snippetToUpdate)
+	if !sameString(snippetToUpdate.OrgID, original.OrgID) {
+		createResponse(w, http.StatusBadRequest, defaultResponse{
+			"Error, the organization a snippet belongs to cannot be changed"})
+		return
+	}
+
+	// who is able to see a snippet and for how long is left to its owner
+	if !original.Role.CanManage() && (snippetToUpdate.Visibility != original.Visibility ||
+		snippetToUpdate.BurnAfterRead != original.BurnAfterRead || !sameTime(snippetToUpdate.ExpiresAt, original.ExpiresAt)) {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owner of this snippet is permitted to change its visibility or expiry"})
+		return
+	}
+
+	// a language that is not supplied is detected again should the file it describes change
+	if snippetToUpdate.Language == "" {
+		snippetToUpdate.Language = language
+		if snippetToUpdate.Filename != filename || snippetToUpdate.Content != content {
+			snippetToUpdate.Language = snippets.DetectLanguage(snippetToUpdate.Filename, snippetToUpdate.Content)
+		}
+	}
+
+	err = snippetToUpdate.Validate()
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if snippetToUpdate.Filename != filename {
+		existingFile, err := sh.SnippetService.SnippetFile(snippetID, snippetToUpdate.Filename)
+		if err != nil {
+			createResponse(w, http.StatusInternalServerError, defaultResponse{
+				"An unexpected error occurred when updating snippet"})
+			return
+		}
+		if existingFile != (snippets.SnippetFile{}) {
+			createResponse(w, http.StatusConflict, defaultResponse{
+				"Error, a file with the same filename already exists in the snippet"})
+			return
+		}
+	}
+
+	err = sh.SnippetService.UpdateSnippet(userInfo.UserID, snippetToUpdate)
 	if err != nil {
 		createResponse(w, http.StatusInternalServerError, defaultResponse{
 			"An unexpected error occurred when updating snippet"})
@@ -167,11 +374,16 @@ func (sh SnippetHandler) handleDeleteSnippet(w http.ResponseWriter, r *http.Requ
 			"An unexpected error occurred when deleting snippet"})
 		return
 	}
-	if snippetToDelete == (snippets.Snippet{}) {
+	if snippetToDelete.ID == "" {
 		createResponse(w, http.StatusNotFound, defaultResponse{
 			"Error, snippet to delete is not found"})
 		return
 	}
+	if !snippetToDelete.Role.CanManage() {
+		createResponse(w, http.StatusForbidden, defaultResponse{
+			"Error, only the owner of this snippet is permitted to do this"})
+		return
+	}
 
 	err = sh.SnippetService.DeleteSnippet(userInfo.UserID, snippetID)
 	if err != nil {
@@ -180,5 +392,59 @@ func (sh SnippetHandler) handleDeleteSnippet(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	createResponse(w, http.StatusOK, defaultResponse{"Snippet is successfully deleted"})
+	createResponse(w, http.StatusOK, defaultResponse{"Snippet is successfully moved to the trash"})
+}
+
+// respondContentTooLarge informs the client that the content it supplied exceeds the given
+// maximum snippet size
+func respondContentTooLarge(w http.ResponseWriter, maxContentSize int64) {
+	createResponse(w, http.StatusRequestEntityTooLarge, defaultResponse{
+		"Snippet content exceeds the maximum size of " + strconv.FormatInt(maxContentSize, 10) + " bytes"})
+}
+
+// viewableSnippet returns the snippet with the given snippetID should the user making the
+// request, as identified by the given Authenticator, own or collaborate on it or should it be
+// public, else, an empty snippets.Snippet is returned
+func viewableSnippet(ss snippets.SnippetService, auth Authenticator, r *http.Request, snippetID string) (snippets.Snippet, error) {
+	if userInfo, err := auth.GetAuthorizationInfo(r); err == nil {
+		snippet, err := ss.Snippet(userInfo.UserID, snippetID)
+		if err != nil || snippet.ID != "" {
+			return snippet, err
+		}
+	}
+	return ss.PublicSnippet(snippetID)
+}
+
+// readSnippet burns the given snippet should it be burnt once read, as its content is about
+// to be served. A conditional or range request for it is served in full, so that it is only
+// burnt when the whole of it is served. A response mentioning the given action and naming
+// the snippet as the given resource is written and false is returned should it fail to, or
+// should another reader have burnt it first
+func readSnippet(w http.ResponseWriter, r *http.Request, ss snippets.SnippetService, snippet snippets.Snippet,
+	resource string, action string) bool {
+	if !burnsOnRead(snippet) {
+		return true
+	}
+	for _, header := range []string{"Range", "If-Range", "If-None-Match", "If-Modified-Since"} {
+		r.Header.Del(header)
+	}
+
+	burnt, err := ss.BurnSnippet(snippet.ID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when " + action})
+		return false
+	}
+	if !burnt {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, requested " + resource + " is not found"})
+		return false
+	}
+	return true
+}
+
+// burnsOnRead reports whether the given snippet is burnt once its content is read, which is
+// when it is burnt after being read and was retrieved for someone without a role on it
+func burnsOnRead(snippet snippets.Snippet) bool {
+	return snippet.BurnAfterRead && snippet.Role == ""
 }
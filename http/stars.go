@@ -0,0 +1,75 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetStarredSnippets
+func (sh SnippetHandler) handleGetStarredSnippets(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving starred snippets"})
+		return
+	}
+
+	snippets, err := sh.SnippetService.StarredSnippets(userInfo.UserID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving starred snippets"})
+		return
+	}
+	createResponse(w, http.StatusOK, snippets)
+}
+
+// handleStarSnippet
+func (sh SnippetHandler) handleStarSnippet(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when starring snippet"})
+		return
+	}
+
+	snippet, err := viewableSnippet(sh.SnippetService, sh.Authenticator, r, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when starring snippet"})
+		return
+	}
+	if snippet.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, snippet to star is not found"})
+		return
+	}
+
+	err = sh.SnippetService.StarSnippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when starring snippet"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Snippet is successfully starred"})
+}
+
+// handleUnstarSnippet
+func (sh SnippetHandler) handleUnstarSnippet(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when unstarring snippet"})
+		return
+	}
+
+	err = sh.SnippetService.UnstarSnippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when unstarring snippet"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Snippet is successfully unstarred"})
+}
@@ -0,0 +1,21 @@
+package http
+
+import "net/http"
+
+// handleGetTags
+func (sh SnippetHandler) handleGetTags(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving tags"})
+		return
+	}
+
+	tags, err := sh.SnippetService.Tags(userInfo.UserID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving tags"})
+		return
+	}
+	createResponse(w, http.StatusOK, tags)
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// handleGetTrashedSnippets
+func (sh SnippetHandler) handleGetTrashedSnippets(w http.ResponseWriter, r *http.Request) {
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving trashed snippets"})
+		return
+	}
+
+	snippets, err := sh.SnippetService.TrashedSnippets(userInfo.UserID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when retrieving trashed snippets"})
+		return
+	}
+	createResponse(w, http.StatusOK, snippets)
+}
+
+// handleRestoreSnippet
+func (sh SnippetHandler) handleRestoreSnippet(w http.ResponseWriter, r *http.Request) {
+	snippetID := mux.Vars(r)["snippetID"]
+	userInfo, err := sh.Authenticator.GetAuthorizationInfo(r)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when restoring snippet"})
+		return
+	}
+
+	snippetToRestore, err := sh.SnippetService.TrashedSnippet(userInfo.UserID, snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when restoring snippet"})
+		return
+	}
+	if snippetToRestore.ID == "" {
+		createResponse(w, http.StatusNotFound, defaultResponse{
+			"Error, snippet to restore is not found in the trash"})
+		return
+	}
+
+	err = sh.SnippetService.RestoreSnippet(snippetID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when restoring snippet"})
+		return
+	}
+	createResponse(w, http.StatusOK, defaultResponse{"Snippet is successfully restored"})
+}
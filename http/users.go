@@ -11,16 +11,19 @@ import (
 // UserHandler is a sub-router that handles requests related to operations on Users
 type UserHandler struct {
 	*mux.Router
-	UserService   snippets.UserService
-	Authenticator Authenticator
+	UserService         snippets.UserService
+	OrganizationService snippets.OrganizationService
+	Authenticator       Authenticator
 }
 
-// NewUserHandler constructs a new UserHandler given a UserService implementation
-func NewUserHandler(us snippets.UserService, auth Authenticator) *UserHandler {
+// NewUserHandler constructs a new UserHandler given a UserService implementation and an
+// OrganizationService implementation to check the organizations a user owns before deleting it
+func NewUserHandler(us snippets.UserService, os snippets.OrganizationService, auth Authenticator) *UserHandler {
 	h := &UserHandler{
-		Router:        mux.NewRouter(),
-		UserService:   us,
-		Authenticator: auth,
+		Router:              mux.NewRouter(),
+		UserService:         us,
+		OrganizationService: os,
+		Authenticator:       auth,
 	}
 
 	verifyUser := verifyRoute(auth)
@@ -36,12 +39,26 @@ func NewUserHandler(us snippets.UserService, auth Authenticator) *UserHandler {
 
 // handleGetUsers
 func (uh UserHandler) handleGetUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := uh.UserService.Users()
+	opts, err := parseListOptions(r, "username", "created", "updated")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+	updatedSince, err := parseTimeParam(r, "updated_since")
+	if err != nil {
+		createResponse(w, http.StatusBadRequest, defaultResponse{err.Error()})
+		return
+	}
+
+	filter := snippets.UserFilter{Username: r.URL.Query().Get("username"), UpdatedSince: updatedSince}
+
+	users, page, err := uh.UserService.Users(filter, opts)
 	if err != nil {
 		createResponse(w, http.StatusInternalServerError, defaultResponse{
 			"An unexpected error occurred when retrieving users"})
 		return
 	}
+	setPageHeaders(w, r, page)
 	createResponse(w, http.StatusOK, users)
 }
 
@@ -182,6 +199,19 @@ func (uh UserHandler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	soleOwnedOrgs, err := uh.OrganizationService.SoleOwnedOrganizations(userID)
+	if err != nil {
+		createResponse(w, http.StatusInternalServerError, defaultResponse{
+			"An unexpected error occurred when deleting user"})
+		return
+	}
+	if len(soleOwnedOrgs) > 0 {
+		createResponse(w, http.StatusConflict, defaultResponse{
+			"Error, user is the only owner of organization " + soleOwnedOrgs[0].Name +
+				", which has to be given another owner or deleted first"})
+		return
+	}
+
 	err = uh.UserService.DeleteUser(userID)
 	if err != nil {
 		createResponse(w, http.StatusInternalServerError, defaultResponse{
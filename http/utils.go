@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
 // defaultResponse represents the default structure of a response body for cases where the
@@ -18,3 +19,36 @@ func createResponse(w http.ResponseWriter, status int, body interface{}) {
 	json.NewEncoder(w).Encode(body)
 	return
 }
+
+// jsonEscapeFactor is the worst-case growth of a string once it has been escaped for JSON
+// (a single byte may be written as a \u00XX sequence)
+const jsonEscapeFactor = 6
+
+// maxRequestBodySize returns the largest request body that still may carry a JSON-encoded
+// payload whose content does not exceed maxContentSize bytes
+func maxRequestBodySize(maxContentSize int64) int64 {
+	return maxContentSize*jsonEscapeFactor + 64*1024
+}
+
+// isRequestBodyTooLarge reports whether err was caused by reading past the limit set
+// on a request body by http.MaxBytesReader
+func isRequestBodyTooLarge(err error) bool {
+	_, ok := err.(*http.MaxBytesError)
+	return ok
+}
+
+// sameString reports whether two optional strings are either both absent or equal
+func sameString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameTime reports whether two optional times are either both absent or the same instant
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -4,22 +4,171 @@ CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 CREATE TABLE account (
     id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
-    email VARCHAR(255) UNIQUE NOT NULL,
-    username VARCHAR(25) UNIQUE NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    username VARCHAR(25) NOT NULL,
     password_hash text NOT NULL,
     first_name VARCHAR(50) NOT NULL,
-    last_name VARCHAR(50) NOT NULL
+    last_name VARCHAR(50) NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
+CREATE UNIQUE INDEX account_email_idx ON account(email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX account_username_idx ON account(username) WHERE deleted_at IS NULL;
+CREATE INDEX account_deleted_at_idx ON account(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE organization (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(25) NOT NULL,
+    display_name VARCHAR(100) NOT NULL DEFAULT '',
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX organization_name_idx ON organization(name) WHERE deleted_at IS NULL;
+CREATE INDEX organization_deleted_at_idx ON organization(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE organization_member (
+    org_id uuid NOT NULL REFERENCES organization(id) ON DELETE CASCADE,
+    account_id uuid NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    role VARCHAR(6) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (org_id, account_id)
+);
+
+CREATE INDEX organization_member_account_id_idx ON organization_member(account_id);
+
 CREATE TABLE snippet (
     id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
-    account_id uuid NOT NULL REFERENCES account(id),
+    account_id uuid REFERENCES account(id) ON DELETE SET NULL,
+    org_id uuid REFERENCES organization(id) ON DELETE CASCADE,
     filename VARCHAR(255) NOT NULL,
     description VARCHAR(255),
-    is_public BOOLEAN NOT NULL
+    visibility VARCHAR(8) NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'unlisted', 'public')),
+    language VARCHAR(50) NOT NULL DEFAULT 'text',
+    share_token VARCHAR(64) UNIQUE,
+    content TEXT NOT NULL DEFAULT '',
+    forked_from uuid REFERENCES snippet(id) ON DELETE SET NULL,
+    search_vector tsvector NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    burn_after_read BOOLEAN NOT NULL DEFAULT FALSE,
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (account_id IS NOT NULL OR org_id IS NOT NULL)
+);
+
+CREATE INDEX snippet_org_id_idx ON snippet(org_id) WHERE org_id IS NOT NULL;
+CREATE INDEX snippet_forked_from_idx ON snippet(forked_from);
+CREATE INDEX snippet_language_idx ON snippet(language);
+CREATE INDEX snippet_expires_at_idx ON snippet(expires_at) WHERE expires_at IS NOT NULL;
+CREATE INDEX snippet_deleted_at_idx ON snippet(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX snippet_search_vector_idx ON snippet USING GIN (search_vector);
+
+CREATE TABLE snippet_file (
+    snippet_id uuid NOT NULL REFERENCES snippet(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (snippet_id, filename)
+);
+
+CREATE TABLE snippet_star (
+    account_id uuid NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    snippet_id uuid NOT NULL REFERENCES snippet(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (account_id, snippet_id)
+);
+
+CREATE INDEX snippet_star_snippet_id_idx ON snippet_star(snippet_id);
+
+CREATE TABLE snippet_collaborator (
+    snippet_id uuid NOT NULL REFERENCES snippet(id) ON DELETE CASCADE,
+    account_id uuid NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    role VARCHAR(6) NOT NULL CHECK (role IN ('viewer', 'editor')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (snippet_id, account_id)
+);
+
+CREATE INDEX snippet_collaborator_account_id_idx ON snippet_collaborator(account_id);
+
+CREATE TABLE collection (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    account_id uuid NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    visibility VARCHAR(8) NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'unlisted', 'public')),
+    share_token VARCHAR(64) UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
-INSERT INTO account VALUES
+CREATE INDEX collection_account_id_idx ON collection(account_id);
+
+CREATE TABLE collection_snippet (
+    collection_id uuid NOT NULL REFERENCES collection(id) ON DELETE CASCADE,
+    snippet_id uuid NOT NULL REFERENCES snippet(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (collection_id, snippet_id)
+);
+
+CREATE INDEX collection_snippet_snippet_id_idx ON collection_snippet(snippet_id);
+
+CREATE TABLE tag (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL
+);
+
+CREATE TABLE snippet_tag (
+    snippet_id uuid NOT NULL REFERENCES snippet(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tag(id) ON DELETE CASCADE,
+    PRIMARY KEY (snippet_id, tag_id)
+);
+
+CREATE INDEX snippet_tag_tag_id_idx ON snippet_tag(tag_id);
+
+CREATE TABLE snippet_revision (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    snippet_id uuid NOT NULL REFERENCES snippet(id) ON DELETE CASCADE,
+    author_id uuid REFERENCES account(id) ON DELETE SET NULL,
+    content_hash CHAR(64) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX snippet_revision_snippet_id_idx ON snippet_revision(snippet_id, created_at);
+
+CREATE TABLE snippet_revision_file (
+    revision_id uuid NOT NULL REFERENCES snippet_revision(id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    is_primary BOOLEAN NOT NULL,
+    PRIMARY KEY (revision_id, filename)
+);
+
+CREATE TABLE snippet_comment (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    snippet_id uuid NOT NULL REFERENCES snippet(id) ON DELETE CASCADE,
+    parent_id uuid REFERENCES snippet_comment(id) ON DELETE SET NULL,
+    author_id uuid REFERENCES account(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    revision_id uuid REFERENCES snippet_revision(id) ON DELETE CASCADE,
+    filename VARCHAR(255),
+    start_line INTEGER CHECK (start_line > 0),
+    end_line INTEGER CHECK (end_line >= start_line),
+    deleted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((revision_id IS NULL) = (filename IS NULL) AND (filename IS NULL) = (start_line IS NULL)
+        AND (start_line IS NULL) = (end_line IS NULL))
+);
+
+CREATE INDEX snippet_comment_snippet_id_idx ON snippet_comment(snippet_id, created_at);
+CREATE INDEX snippet_comment_parent_id_idx ON snippet_comment(parent_id);
+
+INSERT INTO account(id, email, username, password_hash, first_name, last_name) VALUES
 ('6ab591ee-519a-487d-a2b5-27e308f81242', 'admin@snippets.com', 'admin', '$2a$08$ZI4xXeqPoj/noidjiGQy0.jCY7oJbw57ITZD6vMoL6bWuxO84ZMji', 'Admin', 'Test'), -- P@ssw0rd --
 ('1c99fc26-1a69-41d7-bd31-ef8156166917', 'charlotte.l@gmail.com', 'charlottelaw', '$2a$08$kIo02Pqd6fg1aKJhAlYEJexNwSJOH0ZmCjKIKDgrXhtk6Iuz60LHK', 'Charlotte', 'Lawerence'), -- cherrykitty --
 ('9b7c9167-f139-44ea-910e-60211cb389f2', 'johnmendes88@gmail.com', 'johnmendes88', '$2a$08$vwceRFazH7/2.ZLnSliPjuIDUnK5JEgvjlq3rhtSBavbUb1DobJmO', 'John', 'Mendes'); -- too_much88 --
\ No newline at end of file
@@ -0,0 +1,155 @@
+package snippets
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// LanguageText is the language of a snippet whose language could not be detected
+const LanguageText = "text"
+
+// Language represents a programming or markup language a snippet can be written in, along
+// with the hints used to detect it
+type Language struct {
+	Name         string
+	Extensions   []string
+	Filenames    []string
+	Interpreters []string
+	Patterns     []*regexp.Regexp
+}
+
+// Languages is the registry of languages a snippet can be written in. Languages are detected
+// by content patterns in the order they are listed, so more specific ones come first
+var Languages = []Language{
+	{Name: "php", Extensions: []string{".php"}, Interpreters: []string{"php"},
+		Patterns: compilePatterns(`^<\?php`)},
+	{Name: "html", Extensions: []string{".html", ".htm"},
+		Patterns: compilePatterns(`(?i)^\s*<!doctype html`, `(?i)^\s*<html[\s>]`)},
+	{Name: "xml", Extensions: []string{".xml", ".svg", ".xsd", ".xsl"},
+		Patterns: compilePatterns(`^<\?xml\s`)},
+	{Name: "go", Extensions: []string{".go"},
+		Patterns: compilePatterns(`(?m)^package \w+\s*$[\s\S]*^func `)},
+	{Name: "rust", Extensions: []string{".rs"},
+		Patterns: compilePatterns(`(?m)^\s*(pub )?fn \w+\(.*\)( -> .+)? \{`, `(?m)^use \w+(::\w+)+;`)},
+	{Name: "java", Extensions: []string{".java"},
+		Patterns: compilePatterns(`(?m)^\s*public (final )?class \w+`, `(?m)^import java\.`)},
+	{Name: "csharp", Extensions: []string{".cs"},
+		Patterns: compilePatterns(`(?m)^using System(\.\w+)*;`)},
+	{Name: "cpp", Extensions: []string{".cpp", ".cc", ".cxx", ".hpp", ".hh"},
+		Patterns: compilePatterns(`(?m)^#include <(iostream|vector|string|map|memory)>`, `std::`)},
+	{Name: "c", Extensions: []string{".c", ".h"},
+		Patterns: compilePatterns(`(?m)^#include [<"]\w+\.h[>"]`)},
+	{Name: "python", Extensions: []string{".py", ".pyw"}, Interpreters: []string{"python"},
+		Patterns: compilePatterns(`(?m)^def \w+\(.*\):\s*$`, `(?m)^(from \w+(\.\w+)* )?import \w+`)},
+	{Name: "ruby", Extensions: []string{".rb"}, Filenames: []string{"Gemfile", "Rakefile"}, Interpreters: []string{"ruby"},
+		Patterns: compilePatterns(`(?m)^require ['"]\w+['"]`)},
+	{Name: "perl", Extensions: []string{".pl", ".pm"}, Interpreters: []string{"perl"},
+		Patterns: compilePatterns(`(?m)^use strict;`)},
+	{Name: "typescript", Extensions: []string{".ts", ".tsx"}, Interpreters: []string{"ts-node", "deno"}},
+	{Name: "javascript", Extensions: []string{".js", ".mjs", ".cjs", ".jsx"}, Interpreters: []string{"node", "nodejs"},
+		Patterns: compilePatterns(`(?m)^\s*(const|let) \w+ = require\(`, `console\.log\(`)},
+	{Name: "lua", Extensions: []string{".lua"}, Interpreters: []string{"lua"}},
+	{Name: "bash", Extensions: []string{".sh", ".bash"}, Filenames: []string{".bashrc", ".bash_profile", ".profile"},
+		Interpreters: []string{"sh", "bash", "zsh", "dash"}},
+	{Name: "sql", Extensions: []string{".sql"},
+		Patterns: compilePatterns(`(?im)^\s*(select .+ from |create table |insert into )`)},
+	{Name: "css", Extensions: []string{".css"}},
+	{Name: "json", Extensions: []string{".json"},
+		Patterns: compilePatterns(`^\s*\{\s*"[^"]*"\s*:`)},
+	{Name: "yaml", Extensions: []string{".yaml", ".yml"}},
+	{Name: "toml", Extensions: []string{".toml"}},
+	{Name: "markdown", Extensions: []string{".md", ".markdown"}},
+	{Name: "dockerfile", Filenames: []string{"Dockerfile"},
+		Patterns: compilePatterns(`(?m)^FROM \S+[\s\S]*^(RUN|CMD|ENTRYPOINT|COPY) `)},
+	{Name: "makefile", Extensions: []string{".mk"}, Filenames: []string{"Makefile", "GNUmakefile"}},
+	{Name: LanguageText, Extensions: []string{".txt"}},
+}
+
+// IsKnownLanguage reports whether a language with the given name is in the registry
+func IsKnownLanguage(name string) bool {
+	for _, language := range Languages {
+		if language.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectLanguage returns the name of the language a file with the given filename and content
+// is most likely written in. The filename is looked at first, followed by the interpreter
+// named by a shebang line and lastly the content itself, falling back to LanguageText
+func DetectLanguage(filename string, content string) string {
+	base := path.Base(filename)
+	ext := strings.ToLower(path.Ext(base))
+	for _, language := range Languages {
+		for _, name := range language.Filenames {
+			if base == name {
+				return language.Name
+			}
+		}
+		for _, e := range language.Extensions {
+			if ext == e {
+				return language.Name
+			}
+		}
+	}
+
+	if interpreter := shebangInterpreter(content); interpreter != "" {
+		for _, language := range Languages {
+			for _, name := range language.Interpreters {
+				if interpreter == name {
+					return language.Name
+				}
+			}
+		}
+	}
+
+	for _, language := range Languages {
+		for _, pattern := range language.Patterns {
+			if pattern.MatchString(content) {
+				return language.Name
+			}
+		}
+	}
+	return LanguageText
+}
+
+// shebangInterpreter returns the name of the interpreter given in the shebang line content
+// starts with without any version suffix, or an empty string should there be none
+func shebangInterpreter(content string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return ""
+	}
+	line := content[2:]
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 1 {
+		return ""
+	}
+
+	// #!/usr/bin/env python3 names the interpreter as the first argument to env
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		args := fields[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+			args = args[1:]
+		}
+		if len(args) < 1 {
+			return ""
+		}
+		interpreter = path.Base(args[0])
+	}
+	return strings.TrimRight(interpreter, "0123456789.")
+}
+
+// compilePatterns compiles the given regular expressions used to detect a language
+func compilePatterns(patterns ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = regexp.MustCompile(pattern)
+	}
+	return compiled
+}
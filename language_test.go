@@ -0,0 +1,42 @@
+package snippets
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     string
+	}{
+		{"extension", "main.go", "", "go"},
+		{"uppercase extension", "SCRIPT.PY", "", "python"},
+		{"extension in a directory", "src/lib.rs", "", "rust"},
+		{"header extension shared by c and c++", "vector.h", "#include <vector>\nstd::vector<int> v;\n", "c"},
+		{"svg extension", "logo.svg", "<svg></svg>", "xml"},
+		{"extension wins over content", "notes.txt", "package main\n\nfunc main() {}\n", LanguageText},
+		{"extension wins over shebang", "run.py", "#!/bin/bash\necho hi\n", "python"},
+		{"known filename", "Makefile", "all:\n", "makefile"},
+		{"known filename in a directory", "app/Gemfile", "", "ruby"},
+		{"dotfile", ".bashrc", "", "bash"},
+		{"dockerfile", "Dockerfile", "", "dockerfile"},
+		{"no extension with env shebang", "manage", "#!/usr/bin/env python3\nprint(1)\n", "python"},
+		{"no extension with env flags", "serve", "#!/usr/bin/env -S node --harmony\n", "javascript"},
+		{"no extension with shebang", "deploy", "#!/bin/bash\nset -e\n", "bash"},
+		{"no extension with unknown shebang", "tool", "#!/usr/bin/awk -f\n", LanguageText},
+		{"no extension with go content", "snippet", "package main\n\nfunc main() {}\n", "go"},
+		{"no extension with php content", "index", "<?php echo 1;", "php"},
+		{"no extension with html content", "page", "<!DOCTYPE html>\n<html></html>\n", "html"},
+		{"no extension with json content", "data", `{"key": 1}`, "json"},
+		{"unknown extension with content", "config.conf", "SELECT id FROM account;\n", "sql"},
+		{"no extension without hints", "README", "Read me first.\n", LanguageText},
+		{"no extension and no content", "empty", "", LanguageText},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DetectLanguage(test.filename, test.content); got != test.want {
+				t.Errorf("DetectLanguage(%q, %q) = %q, want %q", test.filename, test.content, got, test.want)
+			}
+		})
+	}
+}
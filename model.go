@@ -1,44 +1,363 @@
 package snippets
 
+import (
+	"errors"
+	"io"
+	"time"
+)
+
 // User represents a registered person of this application who can create snippets
 type User struct {
-	ID           string `json:"userId" db:"id"`
-	Email        string `json:"email" db:"email"`
-	Username     string `json:"username" db:"username"`
-	Password     string `json:"password,omitempty"`
-	PasswordHash string `json:"-" db:"password_hash"`
-	FirstName    string `json:"firstName" db:"first_name"`
-	LastName     string `json:"lastName" db:"last_name"`
-	// Created/Updated datetime
+	ID           string     `json:"userId" db:"id"`
+	Email        string     `json:"email" db:"email"`
+	Username     string     `json:"username" db:"username"`
+	Password     string     `json:"password,omitempty"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	FirstName    string     `json:"firstName" db:"first_name"`
+	LastName     string     `json:"lastName" db:"last_name"`
+	DeletedAt    *time.Time `json:"-" db:"deleted_at"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updatedAt" db:"updated_at"`
 }
 
 // UserService provides a set of operations that can be applied on the User struct
 type UserService interface {
 	User(userID string) (User, error)
 	UserByUsername(username string) (User, error)
-	Users() ([]User, error)
+	Users(filter UserFilter, opts ListOptions) ([]User, Page, error)
 	CreateUser(u User) error
 	UpdateUser(updatedUser User) error
 	DeleteUser(userID string) error
+	PurgeDeletedUsers(before time.Time) (int64, error)
+}
+
+// UserFilter narrows down the users returned when listing users to those whose username
+// starts with Username and that were updated since UpdatedSince, should they be given
+type UserFilter struct {
+	Username     string
+	UpdatedSince time.Time
+}
+
+// Organization represents a group of users who own snippets collectively under a shared
+// namespace
+type Organization struct {
+	ID          string     `json:"orgId" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	DisplayName string     `json:"displayName" db:"display_name"`
+	DeletedAt   *time.Time `json:"-" db:"deleted_at"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Membership represents a user belonging to an organization with a given role
+type Membership struct {
+	OrgID     string    `json:"orgId" db:"org_id"`
+	UserID    string    `json:"userId" db:"account_id"`
+	Username  string    `json:"username" db:"username"`
+	Role      OrgRole   `json:"role" db:"role"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 }
 
-// Snippet represents a piece of code published by a user
+// OrgRole determines what a member is permitted to do within an organization
+type OrgRole string
+
+// Every member can view, create and edit the snippets of an organization, an admin can also
+// manage its snippets, details and members while an owner can also manage other owners and
+// delete the organization
+const (
+	OrgRoleMember OrgRole = "member"
+	OrgRoleAdmin  OrgRole = "admin"
+	OrgRoleOwner  OrgRole = "owner"
+)
+
+// CanAdminister reports whether the role permits managing the snippets, details and members
+// of an organization
+func (r OrgRole) CanAdminister() bool {
+	return r == OrgRoleAdmin || r == OrgRoleOwner
+}
+
+// OrganizationService provides a set of operations that can be applied to the Organization
+// and Membership structs
+type OrganizationService interface {
+	Organization(orgID string) (Organization, error)
+	OrganizationByName(name string) (Organization, error)
+	Organizations(userID string) ([]Organization, error)
+	SoleOwnedOrganizations(userID string) ([]Organization, error)
+	CreateOrganization(userID string, o Organization) (string, error)
+	UpdateOrganization(updatedOrg Organization) error
+	DeleteOrganization(orgID string) error
+	Membership(orgID string, userID string) (Membership, error)
+	Memberships(orgID string) ([]Membership, error)
+	SetMembership(m Membership) error
+	RemoveMembership(orgID string, userID string) error
+	OrganizationSnippets(orgID string, filter SnippetFilter, opts ListOptions) ([]Snippet, Page, error)
+	PurgeDeletedOrganizations(before time.Time) (int64, error)
+}
+
+// Snippet represents a piece of code published by a user. A snippet can no longer be read once
+// ExpiresAt has passed, and one that is burnt after being read expires as soon as it is read
+// by anyone but its owner. A snippet that belongs to an organization, given by OrgID, is
+// owned by the organization rather than the user who created it. Role is the role of the
+// user the snippet was retrieved for, should it have been retrieved on behalf of one. A
+// deleted snippet is kept in the trash from DeletedAt until it is restored or purged
 type Snippet struct {
-	ID          string `json:"snippetId" db:"id"`
-	Filename    string `json:"filename" db:"filename"`
-	Description string `json:"description" db:"description"`
-	Public      bool   `json:"isPublic" db:"is_public"`
-	Owner       string `json:"-" db:"account_id"`
-	// Created/Updated datetime
+	ID            string     `json:"snippetId" db:"id"`
+	Filename      string     `json:"filename" db:"filename"`
+	Description   string     `json:"description" db:"description"`
+	Visibility    Visibility `json:"visibility" db:"visibility"`
+	Language      string     `json:"language" db:"language"`
+	ShareToken    *string    `json:"-" db:"share_token"`
+	Content       string     `json:"content" db:"content"`
+	ForkedFrom    *string    `json:"forkedFrom,omitempty" db:"forked_from"`
+	Stars         int        `json:"starCount" db:"star_count"`
+	Tags          []string   `json:"tags" db:"-"`
+	ExpiresAt     *time.Time `json:"expiresAt" db:"expires_at"`
+	BurnAfterRead bool       `json:"burnAfterRead" db:"burn_after_read"`
+	Owner         string     `json:"-" db:"account_id"`
+	OrgID         *string    `json:"orgId,omitempty" db:"org_id"`
+	Role          Role       `json:"role,omitempty" db:"role"`
+	DeletedAt     *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Visibility determines who is able to view a snippet
+type Visibility string
+
+// A private snippet is only visible to its owner, an unlisted snippet is also visible to
+// anyone holding its share token and a public snippet is visible to everyone
+const (
+	VisibilityPrivate  Visibility = "private"
+	VisibilityUnlisted Visibility = "unlisted"
+	VisibilityPublic   Visibility = "public"
+)
+
+// Collection represents a curated and ordered list of snippets that is shared as a whole. A
+// collection is visible to others the same way a snippet is, given its Visibility
+type Collection struct {
+	ID          string     `json:"collectionId" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	Visibility  Visibility `json:"visibility" db:"visibility"`
+	SnippetIDs  []string   `json:"snippetIds" db:"-"`
+	ShareToken  *string    `json:"-" db:"share_token"`
+	Owner       string     `json:"-" db:"account_id"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// CollectionService provides a set of operations that can be applied to the Collection struct
+type CollectionService interface {
+	Collection(userID string, collectionID string) (Collection, error)
+	Collections(userID string) ([]Collection, error)
+	PublicCollection(collectionID string) (Collection, error)
+	PublicCollections() ([]Collection, error)
+	SharedCollection(shareToken string) (Collection, error)
+	CollectionSnippets(collectionID string) ([]Snippet, error)
+	GenerateCollectionShareToken(collectionID string) (string, error)
+	RevokeCollectionShareToken(collectionID string) error
+	CreateCollection(c Collection) (string, error)
+	UpdateCollection(updatedCollection Collection) error
+	DeleteCollection(userID string, collectionID string) error
+}
+
+// Role determines what a user is permitted to do with a snippet
+type Role string
+
+// A viewer can only view a snippet, an editor can also change its content and files while
+// its owner can also share, delete and manage the collaborators of it
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+// CanView reports whether the role permits viewing a snippet
+func (r Role) CanView() bool {
+	return r == RoleViewer || r.CanEdit()
+}
+
+// CanEdit reports whether the role permits changing the content and files of a snippet
+func (r Role) CanEdit() bool {
+	return r == RoleEditor || r.CanManage()
+}
+
+// CanManage reports whether the role permits sharing, deleting and managing the
+// collaborators of a snippet
+func (r Role) CanManage() bool {
+	return r == RoleOwner
+}
+
+// Collaborator represents a user other than the owner of a snippet who has been granted
+// a role on it
+type Collaborator struct {
+	SnippetID string    `json:"snippetId" db:"snippet_id"`
+	UserID    string    `json:"userId" db:"account_id"`
+	Username  string    `json:"username" db:"username"`
+	Role      Role      `json:"role" db:"role"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 }
 
 // SnippetService provides a set of operations that can be applied to the Snippet struct
 type SnippetService interface {
 	Snippet(userID string, snippetID string) (Snippet, error)
-	Snippets(userID string) ([]Snippet, error)
-	CreateSnippet(s Snippet) error
-	UpdateSnippet(updatedSnippet Snippet) error
+	Snippets(userID string, filter SnippetFilter, opts ListOptions) ([]Snippet, Page, error)
+	PublicSnippet(snippetID string) (Snippet, error)
+	PublicSnippets(filter SnippetFilter, opts ListOptions) ([]Snippet, Page, error)
+	SharedSnippet(shareToken string) (Snippet, error)
+	BurnSnippet(snippetID string) (bool, error)
+	GenerateShareToken(snippetID string) (string, error)
+	RevokeShareToken(snippetID string) error
+	ForkSnippet(userID string, snippetID string) (string, error)
+	Forks(userID string, snippetID string) ([]Snippet, error)
+	StarSnippet(userID string, snippetID string) error
+	UnstarSnippet(userID string, snippetID string) error
+	StarredSnippet(userID string, snippetID string) (Snippet, error)
+	StarredSnippets(userID string) ([]Snippet, error)
+	Tags(userID string) ([]TagCount, error)
+	Search(userID string, query string) ([]SearchResult, error)
+	ExportSnippets(userID string, fn func(s Snippet, files []SnippetFile) error) error
+	ImportSnippets(userID string, imports []SnippetImport) ([]SnippetImport, error)
+	PurgeExpiredSnippets() (int64, error)
+	TrashedSnippet(userID string, snippetID string) (Snippet, error)
+	TrashedSnippets(userID string) ([]Snippet, error)
+	RestoreSnippet(snippetID string) error
+	PurgeDeletedSnippets(before time.Time) (int64, error)
+	CreateSnippet(s Snippet, files []SnippetFile) (string, error)
+	UpdateSnippet(userID string, updatedSnippet Snippet) error
+	EditSnippet(userID string, updatedSnippet Snippet, changes []SnippetFileChange) error
 	DeleteSnippet(userID string, snippetID string) error
+	SnippetFile(snippetID string, filename string) (SnippetFile, error)
+	SnippetFiles(snippetID string) ([]SnippetFile, error)
+	AddSnippetFile(userID string, f SnippetFile) error
+	UpdateSnippetFile(userID string, filename string, updatedFile SnippetFile) error
+	RemoveSnippetFile(userID string, snippetID string, filename string) error
+	Revision(snippetID string, revisionID string) (Revision, error)
+	Revisions(snippetID string) ([]Revision, error)
+	RestoreRevision(userID string, snippetID string, revisionID string) error
+	Comment(snippetID string, commentID string) (Comment, error)
+	Comments(snippetID string) ([]Comment, error)
+	CreateComment(c Comment) (string, error)
+	UpdateComment(updatedComment Comment) error
+	DeleteComment(snippetID string, commentID string) error
+	Collaborators(snippetID string) ([]Collaborator, error)
+	SetCollaborator(c Collaborator) error
+	RemoveCollaborator(snippetID string, userID string) error
+}
+
+// SnippetFilter narrows down the snippets returned when listing snippets, a snippet has to
+// have every one of the given Tags, the given Visibility and Language and be updated since
+// UpdatedSince, should they be given, to be listed
+type SnippetFilter struct {
+	Tags         []string
+	Visibility   Visibility
+	Language     string
+	UpdatedSince time.Time
+}
+
+// SnippetFileChange represents a change to the file of a snippet with the given Filename. The
+// file is removed should File be nil, and replaced by File otherwise, which renames it should
+// File carry a different filename. File is added should the snippet have no such file
+type SnippetFileChange struct {
+	Filename string
+	File     *SnippetFile
+}
+
+// SnippetImport represents a snippet to be created by an import along with its files other
+// than the primary one. Once imported, the ID of the snippet is set should it be created and
+// Err is set should it fail to be created
+type SnippetImport struct {
+	Snippet Snippet
+	Files   []SnippetFile
+	Err     error
+}
+
+// TagCount represents a tag along with the number of snippets it is used on
+type TagCount struct {
+	Name  string `json:"name" db:"name"`
+	Count int    `json:"count" db:"count"`
+}
+
+// SearchResult represents a snippet matching a search query, ranked by its relevance and
+// along with fragments of it where the matches are highlighted
+type SearchResult struct {
+	Snippet
+	Rank       float64  `json:"rank" db:"rank"`
+	Highlights []string `json:"highlights" db:"-"`
+}
+
+// SnippetFile represents one of the files that make up a snippet, the first of which is
+// the snippet's own Filename and Content
+type SnippetFile struct {
+	SnippetID string `json:"snippetId" db:"snippet_id"`
+	Filename  string `json:"filename" db:"filename"`
+	Content   string `json:"content" db:"content"`
+}
+
+// Revision represents an immutable snapshot of a snippet and its files, recorded by the
+// user whose change produced it
+type Revision struct {
+	ID          string        `json:"revisionId" db:"id"`
+	SnippetID   string        `json:"snippetId" db:"snippet_id"`
+	Author      string        `json:"authorId" db:"author_id"`
+	ContentHash string        `json:"contentHash" db:"content_hash"`
+	Filename    string        `json:"filename" db:"filename"`
+	Description string        `json:"description" db:"description"`
+	CreatedAt   time.Time     `json:"createdAt" db:"created_at"`
+	Files       []SnippetFile `json:"files,omitempty" db:"-"`
+}
+
+// Comment represents a remark left by a user on a snippet. A comment replying to another
+// comment gives it as its ParentID. A comment can be anchored to the lines StartLine to
+// EndLine of the file with the given Filename as captured by the revision with the given
+// RevisionID, else, it is about the snippet as a whole. A deleted comment that has replies is
+// kept without its body from DeletedAt so that the replies stay in their thread
+type Comment struct {
+	ID         string     `json:"commentId" db:"id"`
+	SnippetID  string     `json:"snippetId" db:"snippet_id"`
+	ParentID   *string    `json:"parentId" db:"parent_id"`
+	Author     string     `json:"authorId" db:"author_id"`
+	Username   string     `json:"username" db:"username"`
+	Body       string     `json:"body" db:"body"`
+	RevisionID *string    `json:"revisionId,omitempty" db:"revision_id"`
+	Filename   *string    `json:"filename,omitempty" db:"filename"`
+	StartLine  *int       `json:"startLine,omitempty" db:"start_line"`
+	EndLine    *int       `json:"endLine,omitempty" db:"end_line"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// IsAnchored reports whether the comment is anchored to lines of a file of a revision
+func (c Comment) IsAnchored() bool {
+	return c.RevisionID != nil || c.Filename != nil || c.StartLine != nil || c.EndLine != nil
+}
+
+// ListOptions describes a page of a list of resources ordered by the key given by Sort. The
+// page starts after the given Cursor, or ends before it should the Cursor point backward,
+// and holds at most Limit resources
+type ListOptions struct {
+	Limit      int
+	Sort       string
+	Descending bool
+	Cursor     *Cursor
+}
+
+// Cursor identifies a position in an ordered list of resources by the sort value and ID of
+// the resource at that position, along with the direction to list in from there. Sort is
+// the sort key of the list the cursor was issued for
+type Cursor struct {
+	Value    string `json:"v"`
+	ID       string `json:"id"`
+	Sort     string `json:"s"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// Page holds the cursors to the pages before and after a page of a list of resources,
+// should there be any
+type Page struct {
+	Prev *Cursor
+	Next *Cursor
 }
 
 // HashUtilities provides a set of operations relating to hashing and hash comparisons
@@ -56,3 +375,19 @@ type AuthenticationService interface {
 type AuthorizationInfo struct {
 	UserID string
 }
+
+// ErrInvalidTokenFormat is returned when a request supplies its authentication token in a
+// format that is not accepted
+var ErrInvalidTokenFormat = errors.New("Invalid token format supplied")
+
+// Highlighter renders code as syntax highlighted HTML
+type Highlighter interface {
+	Highlight(w io.Writer, code string, language string, opts HighlightOptions) error
+	HasTheme(theme string) bool
+}
+
+// HighlightOptions determines how code is rendered by a Highlighter
+type HighlightOptions struct {
+	Theme       string
+	LineNumbers bool
+}
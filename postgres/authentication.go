@@ -17,7 +17,7 @@ type AuthenticationService struct {
 // Authenticate queries the database and verifies a user's credentials
 func (as AuthenticationService) Authenticate(username string, password string) (bool, error) {
 	var passwordHash string
-	err := as.DB.QueryRowx("SELECT password_hash FROM account WHERE username=$1", username).Scan(&passwordHash)
+	err := as.DB.QueryRowx("SELECT password_hash FROM account WHERE username=$1 AND deleted_at IS NULL", username).Scan(&passwordHash)
 	if err == sql.ErrNoRows {
 		return false, nil // no such username exists
 	} else if err != nil {
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+// Collaborators queries the database and returns the collaborators of the snippet with the
+// given snippetID in the order they were added, leaving out the collaborators who have been
+// deleted
+func (ss SnippetService) Collaborators(snippetID string) ([]snippets.Collaborator, error) {
+	collaborators := []snippets.Collaborator{}
+	err := ss.DB.Select(&collaborators, `SELECT c.snippet_id, c.account_id, a.username, c.role, c.created_at
+										FROM snippet_collaborator c JOIN account a ON a.id=c.account_id
+										WHERE c.snippet_id=$1 AND a.deleted_at IS NULL ORDER BY c.created_at, a.username`, snippetID)
+	if err != nil {
+		return nil, errors.New("Error retrieving collaborators: " + err.Error())
+	}
+	return collaborators, nil
+}
+
+// SetCollaborator grants the user of the given collaborator its role on the snippet it
+// references, replacing the role the user previously had on it
+func (ss SnippetService) SetCollaborator(c snippets.Collaborator) error {
+	_, err := ss.DB.NamedExec(`INSERT INTO snippet_collaborator(snippet_id, account_id, role) VALUES(:snippet_id, :account_id, :role)
+								ON CONFLICT (snippet_id, account_id) DO UPDATE SET role=EXCLUDED.role`, c)
+	if err != nil {
+		return errors.New("Error setting collaborator: " + err.Error())
+	}
+	return nil
+}
+
+// RemoveCollaborator revokes the role the user with the given userID has on the snippet
+// with the given snippetID
+func (ss SnippetService) RemoveCollaborator(snippetID string, userID string) error {
+	_, err := ss.DB.Exec("DELETE FROM snippet_collaborator WHERE snippet_id=$1 AND account_id=$2", snippetID, userID)
+	if err != nil {
+		return errors.New("Error removing collaborator: " + err.Error())
+	}
+	return nil
+}
+
+// snippetRole returns the role the user with the given userID has on the snippet with the
+// given snippetID, which is empty should the user neither own nor collaborate on it
+func snippetRole(q sqlx.Queryer, userID string, snippetID string) (snippets.Role, error) {
+	var role snippets.Role
+	err := q.QueryRowx("SELECT "+roleColumn+" FROM snippet WHERE id=$1 AND "+live, snippetID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		return "", errors.New("Error retrieving snippet role: " + err.Error())
+	}
+	return role, nil
+}
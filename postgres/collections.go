@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// CollectionService implements the snippets.CollectionService interface
+type CollectionService struct {
+	DB *sqlx.DB
+}
+
+// collectionColumns lists the columns selected for a snippets.Collection, including the
+// UUIDs of its snippets in the order they are collected
+const collectionColumns = `id, account_id, name, description, visibility, share_token, created_at, updated_at,
+						ARRAY(SELECT snippet_id::text FROM collection_snippet
+							WHERE collection_snippet.collection_id=collection.id ORDER BY position) AS snippet_ids`
+
+// ownerActive is a condition that leaves out the collections of users who have been deleted
+const ownerActive = `collection.account_id IN (SELECT id FROM account WHERE deleted_at IS NULL)`
+
+// collectionRow represents a row selected with collectionColumns, holding the columns that
+// need to be scanned into database specific types
+type collectionRow struct {
+	snippets.Collection
+	SnippetIDs pq.StringArray `db:"snippet_ids"`
+}
+
+// toCollection converts a collectionRow into a snippets.Collection
+func (row collectionRow) toCollection() snippets.Collection {
+	collection := row.Collection
+	collection.SnippetIDs = []string(row.SnippetIDs)
+	if collection.SnippetIDs == nil {
+		collection.SnippetIDs = []string{}
+	}
+	return collection
+}
+
+// Collection queries the database and returns a snippets.Collection instance with the given
+// collectionID should it exist and belong to the user with the given userID
+func (cs CollectionService) Collection(userID string, collectionID string) (snippets.Collection, error) {
+	return getCollection(cs.DB, "SELECT "+collectionColumns+" FROM collection WHERE id=$1 AND account_id=$2", collectionID, userID)
+}
+
+// Collections queries the database and returns every collection of the user with the given
+// userID, ordered by name
+func (cs CollectionService) Collections(userID string) ([]snippets.Collection, error) {
+	return selectCollections(cs.DB, "SELECT "+collectionColumns+" FROM collection WHERE account_id=$1 ORDER BY name, id", userID)
+}
+
+// PublicCollection queries the database and returns a snippets.Collection instance with the
+// given collectionID should it exist and be public, regardless of who owns it, so long as its
+// owner has not been deleted
+func (cs CollectionService) PublicCollection(collectionID string) (snippets.Collection, error) {
+	return getCollection(cs.DB, "SELECT "+collectionColumns+" FROM collection WHERE id=$1 AND visibility='public' AND "+ownerActive,
+		collectionID)
+}
+
+// PublicCollections queries the database and returns every public collection of the users
+// who have not been deleted, most recently updated first
+func (cs CollectionService) PublicCollections() ([]snippets.Collection, error) {
+	return selectCollections(cs.DB, "SELECT "+collectionColumns+" FROM collection WHERE visibility='public' AND "+ownerActive+
+		" ORDER BY updated_at DESC, id")
+}
+
+// SharedCollection queries the database and returns a snippets.Collection instance with the
+// given shareToken should it exist and not be private, regardless of who owns it, so long as
+// its owner has not been deleted
+func (cs CollectionService) SharedCollection(shareToken string) (snippets.Collection, error) {
+	return getCollection(cs.DB, "SELECT "+collectionColumns+" FROM collection WHERE share_token=$1 AND visibility<>'private' AND "+
+		ownerActive, shareToken)
+}
+
+// CollectionSnippets queries the database and returns the snippets of the collection with
+// the given collectionID in the order they are collected. Whoever is able to view a
+// collection is able to view its snippets, so only the snippets that are public or that
+// the owner of the collection is permitted to manage are returned. Snippets that are burnt
+// after being read are left out as listing them would not count as a read
+func (cs CollectionService) CollectionSnippets(collectionID string) ([]snippets.Snippet, error) {
+	var ownerID string
+	err := cs.DB.QueryRowx("SELECT account_id FROM collection WHERE id=$1 AND "+ownerActive, collectionID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return []snippets.Snippet{}, nil
+	} else if err != nil {
+		return nil, errors.New("Error retrieving collection snippets: " + err.Error())
+	}
+
+	return selectSnippets(cs.DB, `SELECT `+snippetColumns+` FROM snippet
+								WHERE id IN (SELECT snippet_id FROM collection_snippet WHERE collection_id=$1)
+								AND `+live+` AND NOT burn_after_read AND (visibility='public' OR `+roleExpr+`='owner')
+								ORDER BY (SELECT position FROM collection_snippet
+										WHERE collection_snippet.collection_id=$1 AND collection_snippet.snippet_id=snippet.id)`,
+		collectionID, ownerID)
+}
+
+// GenerateCollectionShareToken creates a new share token for the collection with the given
+// collectionID, replacing any token it previously had, and returns it
+func (cs CollectionService) GenerateCollectionShareToken(collectionID string) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", errors.New("Error generating share token: " + err.Error())
+	}
+
+	res, err := cs.DB.Exec("UPDATE collection SET share_token=$2 WHERE id=$1", collectionID, token)
+	if err != nil {
+		return "", errors.New("Error generating share token: " + err.Error())
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return "", errors.New("Error checking rows affected after share token generation: " + err.Error())
+	} else if rows < 1 {
+		return "", errors.New("Collection with the given UUID does not exist")
+	}
+	return token, nil
+}
+
+// RevokeCollectionShareToken removes the share token of the collection with the given
+// collectionID so that it can no longer be used to view the collection
+func (cs CollectionService) RevokeCollectionShareToken(collectionID string) error {
+	_, err := cs.DB.Exec("UPDATE collection SET share_token=NULL WHERE id=$1", collectionID)
+	if err != nil {
+		return errors.New("Error revoking share token: " + err.Error())
+	}
+	return nil
+}
+
+// CreateCollection inserts a new collection along with its snippets into the database and
+// returns its ID
+func (cs CollectionService) CreateCollection(c snippets.Collection) (string, error) {
+	var collectionID string
+	err := withTransaction(cs.DB, func(tx *sqlx.Tx) error {
+		query, args, err := tx.BindNamed(`INSERT INTO collection(account_id, name, description, visibility)
+										VALUES(:account_id, :name, :description, :visibility) RETURNING id`, c)
+		if err != nil {
+			return errors.New("Error creating collection: " + err.Error())
+		}
+		err = tx.QueryRowx(query, args...).Scan(&collectionID)
+		if err != nil {
+			return errors.New("Error creating collection: " + err.Error())
+		}
+		return setCollectionSnippets(tx, collectionID, c.SnippetIDs)
+	})
+	if err != nil {
+		return "", err
+	}
+	return collectionID, nil
+}
+
+// UpdateCollection updates an existing collection in the database, replacing its snippets
+// with those of updatedCollection
+func (cs CollectionService) UpdateCollection(updatedCollection snippets.Collection) error {
+	return withTransaction(cs.DB, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExec(`UPDATE collection SET name=:name, description=:description, visibility=:visibility,
+									updated_at=now() WHERE id=:id`, updatedCollection)
+		if err != nil {
+			return errors.New("Error updating collection: " + err.Error())
+		}
+		if rows, err := res.RowsAffected(); err != nil {
+			return errors.New("Error checking rows affected after collection update: " + err.Error())
+		} else if rows < 1 {
+			return errors.New("Collection with the given UUID does not exist")
+		}
+		return setCollectionSnippets(tx, updatedCollection.ID, updatedCollection.SnippetIDs)
+	})
+}
+
+// DeleteCollection removes a collection from the database should its given collectionID
+// exist and belong to the user with the given userID. Its snippets are left as they are
+func (cs CollectionService) DeleteCollection(userID string, collectionID string) error {
+	_, err := cs.DB.Exec("DELETE FROM collection WHERE id=$1 AND account_id=$2", collectionID, userID)
+	if err != nil {
+		return errors.New("Error deleting collection: " + err.Error())
+	}
+	return nil
+}
+
+// setCollectionSnippets replaces the snippets of the collection with the given collectionID
+// with those of the given snippetIDs, keeping them in the order given. A snippet given more
+// than once is only collected at its first position
+func setCollectionSnippets(tx *sqlx.Tx, collectionID string, snippetIDs []string) error {
+	_, err := tx.Exec("DELETE FROM collection_snippet WHERE collection_id=$1", collectionID)
+	if err != nil {
+		return errors.New("Error setting collection snippets: " + err.Error())
+	}
+	_, err = tx.Exec(`INSERT INTO collection_snippet(collection_id, snippet_id, position)
+					SELECT $1::uuid, snippet_id::uuid, min(ord) FROM unnest($2::text[]) WITH ORDINALITY AS s(snippet_id, ord)
+					GROUP BY snippet_id::uuid`,
+		collectionID, pq.Array(snippetIDs))
+	if err != nil {
+		return errors.New("Error setting collection snippets: " + err.Error())
+	}
+	return nil
+}
+
+// getCollection runs a query that selects collectionColumns and returns the resulting row
+// as a snippets.Collection, or an empty one should there be none
+func getCollection(q sqlx.Queryer, query string, args ...interface{}) (snippets.Collection, error) {
+	var row collectionRow
+	err := q.QueryRowx(query, args...).StructScan(&row)
+	if err == sql.ErrNoRows {
+		return snippets.Collection{}, nil
+	} else if err != nil {
+		return snippets.Collection{}, errors.New("Error retrieving collection: " + err.Error())
+	}
+	return row.toCollection(), nil
+}
+
+// selectCollections runs a query that selects collectionColumns and returns the resulting
+// rows as a slice of snippets.Collection
+func selectCollections(q sqlx.Queryer, query string, args ...interface{}) ([]snippets.Collection, error) {
+	collections := []snippets.Collection{}
+	rows, err := q.Queryx(query, args...)
+	if err != nil {
+		return nil, errors.New("Error retrieving collections: " + err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var row collectionRow
+		err := rows.StructScan(&row)
+		if err != nil {
+			return nil, errors.New("Error retrieving collections: " + err.Error())
+		}
+		collections = append(collections, row.toCollection())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("Error retrieving collections: " + err.Error())
+	}
+
+	return collections, nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+// commentQuery selects the columns of a snippets.Comment, including the username of its
+// author, to be narrowed down with a WHERE clause on the comment c. The author of a comment
+// is empty once their account has been purged
+const commentQuery = `SELECT c.id, c.snippet_id, c.parent_id, COALESCE(c.author_id::text, '') AS author_id,
+						COALESCE(a.username, '') AS username, c.body, c.revision_id, c.filename, c.start_line, c.end_line,
+						c.deleted_at, c.created_at, c.updated_at
+						FROM snippet_comment c LEFT JOIN account a ON a.id=c.author_id`
+
+// hasReplies is a condition that holds for the comments that have been replied to
+const hasReplies = `EXISTS (SELECT 1 FROM snippet_comment reply WHERE reply.parent_id=snippet_comment.id)`
+
+// Comment queries the database and returns the comment with the given commentID should it
+// belong to the snippet with the given snippetID
+func (ss SnippetService) Comment(snippetID string, commentID string) (snippets.Comment, error) {
+	var comment snippets.Comment
+	err := ss.DB.QueryRowx(commentQuery+" WHERE c.id=$1 AND c.snippet_id=$2", commentID, snippetID).StructScan(&comment)
+	if err == sql.ErrNoRows {
+		return comment, nil
+	} else if err != nil {
+		return comment, errors.New("Error retrieving comment: " + err.Error())
+	}
+	return comment, nil
+}
+
+// Comments queries the database and returns every comment on the snippet with the given
+// snippetID, replies included, from the earliest to the most recent one
+func (ss SnippetService) Comments(snippetID string) ([]snippets.Comment, error) {
+	comments := []snippets.Comment{}
+	err := ss.DB.Select(&comments, commentQuery+" WHERE c.snippet_id=$1 ORDER BY c.created_at, c.id", snippetID)
+	if err != nil {
+		return nil, errors.New("Error retrieving comments: " + err.Error())
+	}
+	return comments, nil
+}
+
+// CreateComment inserts a new comment into the database for the snippet it references and
+// returns its ID
+func (ss SnippetService) CreateComment(c snippets.Comment) (string, error) {
+	query, args, err := ss.DB.BindNamed(`INSERT INTO snippet_comment(snippet_id, parent_id, author_id, body, revision_id, filename, start_line, end_line)
+										VALUES(:snippet_id, :parent_id, :author_id, :body, :revision_id, :filename, :start_line, :end_line)
+										RETURNING id`, c)
+	if err != nil {
+		return "", errors.New("Error creating comment: " + err.Error())
+	}
+	var commentID string
+	err = ss.DB.QueryRowx(query, args...).Scan(&commentID)
+	if err != nil {
+		return "", errors.New("Error creating comment: " + err.Error())
+	}
+	return commentID, nil
+}
+
+// UpdateComment updates the body of an existing comment in the database. Neither the
+// snippet, the parent nor the anchor of a comment change once it is created
+func (ss SnippetService) UpdateComment(updatedComment snippets.Comment) error {
+	res, err := ss.DB.NamedExec(`UPDATE snippet_comment SET body=:body, updated_at=now()
+								WHERE id=:id AND snippet_id=:snippet_id`, updatedComment)
+	if err != nil {
+		return errors.New("Error updating comment: " + err.Error())
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return errors.New("Error checking rows affected after comment update: " + err.Error())
+	} else if rows < 1 {
+		return errors.New("Comment with the given UUID does not exist")
+	}
+	return nil
+}
+
+// DeleteComment removes the comment with the given commentID from the snippet with the given
+// snippetID. Should it have replies, which may be written by others, it is only marked as
+// deleted and cleared of its body so that the replies are kept
+func (ss SnippetService) DeleteComment(snippetID string, commentID string) error {
+	return withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		return removeComments(tx, "id=$1 AND snippet_id=$2", commentID, snippetID)
+	})
+}
+
+// removeComments removes the comments that satisfy the given condition, keeping those that
+// have replies as deleted comments without their body
+func removeComments(tx *sqlx.Tx, condition string, args ...interface{}) error {
+	_, err := tx.Exec(`UPDATE snippet_comment SET body='', deleted_at=now(), updated_at=now()
+						WHERE deleted_at IS NULL AND `+hasReplies+` AND `+condition, args...)
+	if err != nil {
+		return errors.New("Error deleting comments: " + err.Error())
+	}
+	_, err = tx.Exec("DELETE FROM snippet_comment WHERE NOT "+hasReplies+" AND "+condition, args...)
+	if err != nil {
+		return errors.New("Error deleting comments: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+// openTestDB connects to the database given by TEST_DB_URL, which is expected to have been
+// set up with init.sql, skipping the test should it not be set
+func openTestDB(t *testing.T) *sqlx.DB {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+	db, err := Open(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// createTestUser inserts a user with a unique username into the database and returns its ID
+func createTestUser(t *testing.T, db *sqlx.DB, name string) string {
+	username := name + strconv.FormatInt(time.Now().UnixNano()%1e12, 10)
+	var userID string
+	err := db.QueryRowx(`INSERT INTO account(email, username, password_hash, first_name, last_name)
+						VALUES($1, $2, '', $3, $3) RETURNING id`, username+"@example.com", username, name).Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return userID
+}
+
+func TestDeleteCommentKeepsRepliesOfOthers(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	ss := SnippetService{DB: db}
+
+	authorID := createTestUser(t, db, "author")
+	replierID := createTestUser(t, db, "replier")
+	defer db.Exec("DELETE FROM account WHERE id IN ($1, $2)", authorID, replierID)
+
+	var snippetID string
+	err := db.QueryRowx("INSERT INTO snippet(account_id, filename) VALUES($1, 'main.go') RETURNING id", authorID).Scan(&snippetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Exec("DELETE FROM snippet WHERE id=$1", snippetID)
+
+	parentID, err := ss.CreateComment(snippets.Comment{SnippetID: snippetID, Author: authorID, Body: "parent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replyID, err := ss.CreateComment(snippets.Comment{SnippetID: snippetID, ParentID: &parentID, Author: replierID, Body: "reply"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ss.DeleteComment(snippetID, parentID); err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := ss.Comment(snippetID, replyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != replyID || reply.Body != "reply" || reply.ParentID == nil || *reply.ParentID != parentID {
+		t.Errorf("expected the reply to be kept under its parent, got %+v", reply)
+	}
+
+	parent, err := ss.Comment(snippetID, parentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent.ID != parentID || parent.DeletedAt == nil || parent.Body != "" {
+		t.Errorf("expected the parent to be kept as deleted without its body, got %+v", parent)
+	}
+
+	if err = ss.DeleteComment(snippetID, replyID); err != nil {
+		t.Fatal(err)
+	}
+	reply, err = ss.Comment(snippetID, replyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != "" {
+		t.Errorf("expected the reply without replies to be removed, got %+v", reply)
+	}
+}
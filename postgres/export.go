@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"github.com/chuabingquan/snippets"
+)
+
+// exportBatchSize is the number of snippets held in memory at a time while exporting them
+const exportBatchSize = 10
+
+// ExportSnippets calls fn with every snippet owned by the user with the given userID along
+// with its files, one snippet at a time and in the order of their UUIDs. Snippets are
+// retrieved in small batches so that exporting a large account does not hold all of it in
+// memory. Exporting stops at the first error returned by fn
+func (ss SnippetService) ExportSnippets(userID string, fn func(s snippets.Snippet, files []snippets.SnippetFile) error) error {
+	lastID := ""
+	for {
+		batch, err := selectSnippets(ss.DB, `SELECT `+snippetColumns+` FROM snippet
+											WHERE account_id=$1 AND org_id IS NULL AND `+live+` AND ($2='' OR id>NULLIF($2, '')::uuid)
+											ORDER BY id LIMIT $3`, userID, lastID, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, snippet := range batch {
+			files, err := snippetFiles(ss.DB, snippet.ID)
+			if err != nil {
+				return err
+			}
+			if err = fn(snippet, files); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+// ForkSnippet copies the snippet with the given snippetID, its files and tags into a new private
+// snippet owned by the user with the given userID, recording the snippet it was forked from.
+// The ID of the new snippet is returned
+func (ss SnippetService) ForkSnippet(userID string, snippetID string) (string, error) {
+	var forkID string
+	err := withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowx(`INSERT INTO snippet(account_id, filename, description, visibility, language, content, forked_from)
+							SELECT $1, filename, description, 'private', language, content, id FROM snippet WHERE id=$2
+							RETURNING id`, userID, snippetID).Scan(&forkID)
+		if err != nil {
+			return errors.New("Error forking snippet: " + err.Error())
+		}
+
+		_, err = tx.Exec(`INSERT INTO snippet_file(snippet_id, filename, content)
+						SELECT $1, filename, content FROM snippet_file WHERE snippet_id=$2`, forkID, snippetID)
+		if err != nil {
+			return errors.New("Error forking snippet files: " + err.Error())
+		}
+
+		_, err = tx.Exec(`INSERT INTO snippet_tag(snippet_id, tag_id)
+						SELECT $1, tag_id FROM snippet_tag WHERE snippet_id=$2`, forkID, snippetID)
+		if err != nil {
+			return errors.New("Error forking snippet tags: " + err.Error())
+		}
+
+		return recordChange(tx, userID, forkID)
+	})
+	if err != nil {
+		return "", err
+	}
+	return forkID, nil
+}
+
+// Forks queries the database and returns the snippets forked from the snippet with the given
+// snippetID that are public or that the user with the given userID, which may be empty for
+// anonymous users, has a role on. Public forks that are burnt after being read are left out.
+// Each fork carries the role of the user on it
+func (ss SnippetService) Forks(userID string, snippetID string) ([]snippets.Snippet, error) {
+	return selectSnippets(ss.DB, `SELECT `+snippetColumns+`, `+roleColumn+` FROM snippet WHERE forked_from=$1 AND `+live+`
+								AND ((visibility='public' AND NOT burn_after_read) OR `+roleExpr+`<>'')`,
+		snippetID, nullString(userID))
+}
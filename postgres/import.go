@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+// ImportSnippets creates every one of the given snippets and their files for the user with
+// the given userID within a single transaction. Each snippet is created under a savepoint so
+// that one failing to be created does not prevent the rest from being created. The imports
+// are returned with the IDs of the created snippets or the errors of the failed ones set
+func (ss SnippetService) ImportSnippets(userID string, imports []snippets.SnippetImport) ([]snippets.SnippetImport, error) {
+	results := make([]snippets.SnippetImport, len(imports))
+	err := withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		for i, imp := range imports {
+			imp.Snippet.Owner = userID
+			if _, err := tx.Exec("SAVEPOINT import_snippet"); err != nil {
+				return errors.New("Error importing snippets: " + err.Error())
+			}
+
+			snippetID, err := importSnippet(tx, userID, imp)
+			if err != nil {
+				if _, err := tx.Exec("ROLLBACK TO SAVEPOINT import_snippet"); err != nil {
+					return errors.New("Error importing snippets: " + err.Error())
+				}
+				imp.Err = err
+			} else {
+				if _, err := tx.Exec("RELEASE SAVEPOINT import_snippet"); err != nil {
+					return errors.New("Error importing snippets: " + err.Error())
+				}
+				imp.Snippet.ID = snippetID
+			}
+			results[i] = imp
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// importSnippet creates the snippet of the given import along with its files, recording it
+// as a revision authored by the user with the given userID, and returns its ID
+func importSnippet(tx *sqlx.Tx, userID string, imp snippets.SnippetImport) (string, error) {
+	snippetID, err := insertSnippet(tx, imp.Snippet)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range imp.Files {
+		_, err = tx.Exec("INSERT INTO snippet_file(snippet_id, filename, content) VALUES($1, $2, $3)",
+			snippetID, file.Filename, file.Content)
+		if err != nil {
+			return "", errors.New("Error adding snippet file: " + err.Error())
+		}
+	}
+	return snippetID, recordChange(tx, userID, snippetID)
+}
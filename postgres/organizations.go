@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+// OrganizationService implements the snippets.OrganizationService interface
+type OrganizationService struct {
+	DB *sqlx.DB
+}
+
+// Organization queries the database and returns a snippets.Organization instance with the
+// given orgID should it exist and not have been deleted
+func (os OrganizationService) Organization(orgID string) (snippets.Organization, error) {
+	return getOrganization(os.DB, "SELECT * FROM organization WHERE id=$1 AND deleted_at IS NULL", orgID)
+}
+
+// OrganizationByName performs the same operation as Organization but takes in the name of
+// an organization instead of its orgID
+func (os OrganizationService) OrganizationByName(name string) (snippets.Organization, error) {
+	return getOrganization(os.DB, "SELECT * FROM organization WHERE name=$1 AND deleted_at IS NULL", name)
+}
+
+// Organizations queries the database and returns every organization the user with the given
+// userID is a member of, ordered by name
+func (os OrganizationService) Organizations(userID string) ([]snippets.Organization, error) {
+	orgs := []snippets.Organization{}
+	err := os.DB.Select(&orgs, `SELECT * FROM organization WHERE deleted_at IS NULL
+								AND id IN (SELECT org_id FROM organization_member WHERE account_id=$1) ORDER BY name`, userID)
+	if err != nil {
+		return nil, errors.New("Error retrieving organizations: " + err.Error())
+	}
+	return orgs, nil
+}
+
+// SoleOwnedOrganizations queries the database and returns every organization that the user
+// with the given userID is the only owner of, leaving out the owners who have been deleted,
+// ordered by name
+func (os OrganizationService) SoleOwnedOrganizations(userID string) ([]snippets.Organization, error) {
+	orgs := []snippets.Organization{}
+	err := os.DB.Select(&orgs, `SELECT * FROM organization o WHERE o.deleted_at IS NULL
+								AND EXISTS (SELECT 1 FROM organization_member WHERE org_id=o.id AND account_id=$1 AND role=$2)
+								AND NOT EXISTS (SELECT 1 FROM organization_member m JOIN account a ON a.id=m.account_id
+									WHERE m.org_id=o.id AND m.account_id<>$1 AND m.role=$2 AND a.deleted_at IS NULL)
+								ORDER BY o.name`, userID, snippets.OrgRoleOwner)
+	if err != nil {
+		return nil, errors.New("Error retrieving solely owned organizations: " + err.Error())
+	}
+	return orgs, nil
+}
+
+// CreateOrganization inserts a new organization into the database with the user with the
+// given userID as its owner and returns its ID
+func (os OrganizationService) CreateOrganization(userID string, o snippets.Organization) (string, error) {
+	var orgID string
+	err := withTransaction(os.DB, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowx("INSERT INTO organization(name, display_name) VALUES($1, $2) RETURNING id",
+			o.Name, o.DisplayName).Scan(&orgID)
+		if err != nil {
+			return errors.New("Error creating organization: " + err.Error())
+		}
+
+		_, err = tx.Exec("INSERT INTO organization_member(org_id, account_id, role) VALUES($1, $2, $3)",
+			orgID, userID, snippets.OrgRoleOwner)
+		if err != nil {
+			return errors.New("Error adding organization owner: " + err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return orgID, nil
+}
+
+// UpdateOrganization takes in a snippets.Organization instance and updates the relevant
+// database organization record accordingly
+func (os OrganizationService) UpdateOrganization(updatedOrg snippets.Organization) error {
+	res, err := os.DB.NamedExec(`UPDATE organization SET name=:name, display_name=:display_name, updated_at=now()
+								WHERE id=:id AND deleted_at IS NULL`, updatedOrg)
+	if err != nil {
+		return errors.New("Error updating organization: " + err.Error())
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return errors.New("Error checking rows affected after organization update: " + err.Error())
+	} else if rows < 1 {
+		return errors.New("Organization with the given UUID does not exist")
+	}
+	return nil
+}
+
+// DeleteOrganization marks the organization with the given orgID as deleted, removing its
+// memberships and moving the snippets it owns to the trash. Those snippets cannot be
+// restored, as the organization itself cannot, and are left out of the trash until they are
+// removed from the database along with the organization once it is purged
+func (os OrganizationService) DeleteOrganization(orgID string) error {
+	return withTransaction(os.DB, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("UPDATE snippet SET deleted_at=now() WHERE org_id=$1 AND deleted_at IS NULL", orgID)
+		if err != nil {
+			return errors.New("Error deleting organization snippets: " + err.Error())
+		}
+		_, err = tx.Exec("DELETE FROM organization_member WHERE org_id=$1", orgID)
+		if err != nil {
+			return errors.New("Error deleting organization memberships: " + err.Error())
+		}
+		_, err = tx.Exec("UPDATE organization SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", orgID)
+		if err != nil {
+			return errors.New("Error deleting organization: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// PurgeDeletedOrganizations removes every organization deleted before the given time from
+// the database along with its snippets, and returns the number of organizations removed
+func (os OrganizationService) PurgeDeletedOrganizations(before time.Time) (int64, error) {
+	res, err := os.DB.Exec("DELETE FROM organization WHERE deleted_at<=$1", before)
+	if err != nil {
+		return 0, errors.New("Error purging deleted organizations: " + err.Error())
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.New("Error checking rows affected after purging deleted organizations: " + err.Error())
+	}
+	return rows, nil
+}
+
+// Membership queries the database and returns the membership of the user with the given
+// userID in the organization with the given orgID should the user be a member of it and not
+// have been deleted
+func (os OrganizationService) Membership(orgID string, userID string) (snippets.Membership, error) {
+	var membership snippets.Membership
+	err := os.DB.QueryRowx(`SELECT m.org_id, m.account_id, a.username, m.role, m.created_at
+							FROM organization_member m JOIN account a ON a.id=m.account_id
+							WHERE m.org_id=$1 AND m.account_id=$2 AND a.deleted_at IS NULL`, orgID, userID).StructScan(&membership)
+	if err == sql.ErrNoRows {
+		return membership, nil
+	} else if err != nil {
+		return membership, errors.New("Error retrieving membership: " + err.Error())
+	}
+	return membership, nil
+}
+
+// Memberships queries the database and returns the memberships of the organization with the
+// given orgID in the order its members joined, leaving out the members who have been deleted
+func (os OrganizationService) Memberships(orgID string) ([]snippets.Membership, error) {
+	memberships := []snippets.Membership{}
+	err := os.DB.Select(&memberships, `SELECT m.org_id, m.account_id, a.username, m.role, m.created_at
+										FROM organization_member m JOIN account a ON a.id=m.account_id
+										WHERE m.org_id=$1 AND a.deleted_at IS NULL ORDER BY m.created_at, a.username`, orgID)
+	if err != nil {
+		return nil, errors.New("Error retrieving memberships: " + err.Error())
+	}
+	return memberships, nil
+}
+
+// SetMembership adds the user of the given membership to the organization it references with
+// its role, replacing the role the user previously had in it
+func (os OrganizationService) SetMembership(m snippets.Membership) error {
+	_, err := os.DB.NamedExec(`INSERT INTO organization_member(org_id, account_id, role) VALUES(:org_id, :account_id, :role)
+								ON CONFLICT (org_id, account_id) DO UPDATE SET role=EXCLUDED.role`, m)
+	if err != nil {
+		return errors.New("Error setting membership: " + err.Error())
+	}
+	return nil
+}
+
+// RemoveMembership removes the user with the given userID from the organization with the
+// given orgID. The snippets the user created for the organization remain with it
+func (os OrganizationService) RemoveMembership(orgID string, userID string) error {
+	_, err := os.DB.Exec("DELETE FROM organization_member WHERE org_id=$1 AND account_id=$2", orgID, userID)
+	if err != nil {
+		return errors.New("Error removing membership: " + err.Error())
+	}
+	return nil
+}
+
+// OrganizationSnippets queries the database and returns a page of the snippets owned by the
+// organization with the given orgID, narrowed down by the given filter
+func (os OrganizationService) OrganizationSnippets(orgID string, filter snippets.SnippetFilter,
+	opts snippets.ListOptions) ([]snippets.Snippet, snippets.Page, error) {
+	return listSnippets(os.DB, "org_id=$1", orgID, filter, opts)
+}
+
+// getOrganization runs a query that selects an organization and returns the resulting row,
+// or an empty snippets.Organization should there be none
+func getOrganization(q sqlx.Queryer, query string, args ...interface{}) (snippets.Organization, error) {
+	var org snippets.Organization
+	err := q.QueryRowx(query, args...).StructScan(&org)
+	if err == sql.ErrNoRows {
+		return org, nil
+	} else if err != nil {
+		return org, errors.New("Error retrieving organization: " + err.Error())
+	}
+	return org, nil
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"strconv"
+
+	"github.com/chuabingquan/snippets"
+)
+
+// keysetClause returns the clause that narrows down a query to the page described by opts,
+// ordering it by the given column with the row's id as a tie-breaker. The clause's parameters
+// are appended to args, which are numbered after the ones already in args. One row more than
+// the page's limit is selected so that it can be told whether there are further rows
+func keysetClause(column string, opts snippets.ListOptions, args []interface{}) (string, []interface{}) {
+	// rows are selected in reverse order when listing backward from the cursor
+	descending := opts.Descending
+	if opts.Cursor != nil && opts.Cursor.Backward {
+		descending = !descending
+	}
+	comparison, order := ">", "ASC"
+	if descending {
+		comparison, order = "<", "DESC"
+	}
+
+	clause := ""
+	if opts.Cursor != nil {
+		args = append(args, opts.Cursor.Value, opts.Cursor.ID)
+		clause = " AND (" + column + ", id) " + comparison + " ($" + strconv.Itoa(len(args)-1) + ", $" + strconv.Itoa(len(args)) + "::uuid)"
+	}
+	args = append(args, opts.Limit+1)
+	clause += " ORDER BY " + column + " " + order + ", id " + order + " LIMIT $" + strconv.Itoa(len(args))
+	return clause, args
+}
+
+// keysetPage works out which of the n rows selected with keysetClause belong to the page
+// described by opts. It returns the indexes of those rows in the order they are listed,
+// along with whether there are rows before and after the page
+func keysetPage(n int, opts snippets.ListOptions) (indexes []int, hasPrev bool, hasNext bool) {
+	backward := opts.Cursor != nil && opts.Cursor.Backward
+	hasMore := n > opts.Limit
+	if hasMore {
+		n = opts.Limit
+	}
+
+	indexes = make([]int, n)
+	for i := range indexes {
+		if backward {
+			indexes[i] = n - 1 - i
+		} else {
+			indexes[i] = i
+		}
+	}
+
+	if backward {
+		return indexes, hasMore, true
+	}
+	return indexes, opts.Cursor != nil, hasMore
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+// Revision queries the database and returns the revision with the given revisionID, along
+// with the files it captured, should it belong to the snippet with the given snippetID
+func (ss SnippetService) Revision(snippetID string, revisionID string) (snippets.Revision, error) {
+	return revision(ss.DB, snippetID, revisionID)
+}
+
+// Revisions queries the database and returns the revisions of the snippet with the given
+// snippetID without their files, starting from the most recent one
+func (ss SnippetService) Revisions(snippetID string) ([]snippets.Revision, error) {
+	revisions := []snippets.Revision{}
+	rows, err := ss.DB.Queryx(`SELECT id, snippet_id, COALESCE(author_id::text, '') AS author_id, content_hash, filename, description, created_at
+								FROM snippet_revision WHERE snippet_id=$1 ORDER BY created_at DESC, id`, snippetID)
+	if err != nil {
+		return nil, errors.New("Error retrieving revisions: " + err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision snippets.Revision
+		err := rows.StructScan(&revision)
+		if err != nil {
+			return nil, errors.New("Error retrieving revisions: " + err.Error())
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("Error retrieving revisions: " + err.Error())
+	}
+
+	return revisions, nil
+}
+
+// RestoreRevision makes the files of the revision with the given revisionID the current
+// files of the snippet with the given snippetID should the user with the given userID be
+// permitted to edit it. Restoring does not rewrite history but is recorded as a new revision
+// authored by the user
+func (ss SnippetService) RestoreRevision(userID string, snippetID string, revisionID string) error {
+	return withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		role, err := snippetRole(tx, userID, snippetID)
+		if err != nil {
+			return err
+		}
+		if !role.CanEdit() {
+			return errors.New("User is not permitted to edit the snippet")
+		}
+
+		revision, err := revision(tx, snippetID, revisionID)
+		if err != nil {
+			return err
+		}
+		if revision.ID == "" || len(revision.Files) < 1 {
+			return errors.New("Revision with the given UUID does not exist")
+		}
+
+		primaryFile := revision.Files[0]
+		_, err = tx.Exec("UPDATE snippet SET filename=$2, description=$3, content=$4 WHERE id=$1",
+			snippetID, primaryFile.Filename, revision.Description, primaryFile.Content)
+		if err != nil {
+			return errors.New("Error restoring revision: " + err.Error())
+		}
+
+		_, err = tx.Exec("DELETE FROM snippet_file WHERE snippet_id=$1", snippetID)
+		if err != nil {
+			return errors.New("Error restoring revision: " + err.Error())
+		}
+		for _, file := range revision.Files[1:] {
+			_, err = tx.NamedExec(`INSERT INTO snippet_file(snippet_id, filename, content) VALUES(:snippet_id, :filename, :content)`, file)
+			if err != nil {
+				return errors.New("Error restoring revision: " + err.Error())
+			}
+		}
+
+		return recordChange(tx, userID, snippetID)
+	})
+}
+
+// revision returns the revision with the given revisionID and its files should it belong
+// to the snippet with the given snippetID
+func revision(q sqlx.Queryer, snippetID string, revisionID string) (snippets.Revision, error) {
+	var revision snippets.Revision
+	err := q.QueryRowx(`SELECT id, snippet_id, COALESCE(author_id::text, '') AS author_id, content_hash, filename, description, created_at
+						FROM snippet_revision WHERE id=$1 AND snippet_id=$2`, revisionID, snippetID).StructScan(&revision)
+	if err == sql.ErrNoRows {
+		return revision, nil
+	} else if err != nil {
+		return revision, errors.New("Error retrieving revision: " + err.Error())
+	}
+
+	rows, err := q.Queryx(`SELECT r.snippet_id, f.filename, f.content FROM snippet_revision_file f
+							JOIN snippet_revision r ON r.id = f.revision_id
+							WHERE f.revision_id=$1 ORDER BY f.is_primary DESC, f.filename`, revisionID)
+	if err != nil {
+		return revision, errors.New("Error retrieving revision files: " + err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var file snippets.SnippetFile
+		err := rows.StructScan(&file)
+		if err != nil {
+			return revision, errors.New("Error retrieving revision files: " + err.Error())
+		}
+		revision.Files = append(revision.Files, file)
+	}
+
+	if err = rows.Err(); err != nil {
+		return revision, errors.New("Error retrieving revision files: " + err.Error())
+	}
+
+	return revision, nil
+}
+
+// createRevision snapshots the current state of the snippet with the given snippetID as a
+// new revision authored by the user with the given userID. No revision is created should
+// the snippet be unchanged since its latest revision
+func createRevision(tx *sqlx.Tx, userID string, snippetID string) error {
+	files, err := snippetFiles(tx, snippetID)
+	if err != nil {
+		return err
+	}
+	if len(files) < 1 {
+		return errors.New("Snippet with the given UUID does not exist")
+	}
+
+	var description string
+	err = tx.QueryRowx("SELECT COALESCE(description, '') FROM snippet WHERE id=$1", snippetID).Scan(&description)
+	if err != nil {
+		return errors.New("Error creating revision: " + err.Error())
+	}
+
+	contentHash := hashSnippetFiles(files)
+
+	var latestHash, latestDescription string
+	err = tx.QueryRowx(`SELECT content_hash, description FROM snippet_revision WHERE snippet_id=$1
+						ORDER BY created_at DESC, id LIMIT 1`, snippetID).Scan(&latestHash, &latestDescription)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.New("Error creating revision: " + err.Error())
+	}
+	if err == nil && latestHash == contentHash && latestDescription == description {
+		return nil
+	}
+
+	var revisionID string
+	err = tx.QueryRowx(`INSERT INTO snippet_revision(snippet_id, author_id, content_hash, filename, description)
+						VALUES($1, $2, $3, $4, $5) RETURNING id`,
+		snippetID, userID, contentHash, files[0].Filename, description).Scan(&revisionID)
+	if err != nil {
+		return errors.New("Error creating revision: " + err.Error())
+	}
+
+	for i, file := range files {
+		_, err = tx.Exec("INSERT INTO snippet_revision_file(revision_id, filename, content, is_primary) VALUES($1, $2, $3, $4)",
+			revisionID, file.Filename, file.Content, i == 0)
+		if err != nil {
+			return errors.New("Error creating revision: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// hashSnippetFiles computes a hex encoded SHA-256 hash over the names and contents of the
+// given files in the order they are given
+func hashSnippetFiles(files []snippets.SnippetFile) string {
+	h := sha256.New()
+	for _, file := range files {
+		h.Write([]byte(file.Filename))
+		h.Write([]byte{0})
+		h.Write([]byte(file.Content))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"errors"
+	"html"
+	"strings"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxSearchResults is the maximum number of results returned for a search
+const maxSearchResults = 50
+
+// maxIndexedContent is the number of characters of a snippet's content that are indexed,
+// keeping its search vector within the size PostgreSQL allows
+const maxIndexedContent = 262144
+
+// Private use characters that delimit highlights and fragments in ts_headline's output, so
+// that highlights can be marked up after the fragments have been escaped
+const (
+	highlightStart    = "\uE000"
+	highlightStop     = "\uE001"
+	fragmentDelimiter = "\uE002"
+)
+
+// headlineOptions configures ts_headline to return the fragments of a snippet that match
+const headlineOptions = "MaxFragments=3, MaxWords=20, MinWords=5, StartSel=" + highlightStart +
+	", StopSel=" + highlightStop + ", FragmentDelimiter=" + fragmentDelimiter
+
+// searchRow represents a row selected by Search
+type searchRow struct {
+	snippetRow
+	Rank     float64 `db:"rank"`
+	Headline string  `db:"headline"`
+}
+
+// Search performs a ranked full-text search over the filenames, descriptions, tags and
+// contents of the snippets the user with the given userID, which may be empty for anonymous
+// users, has a role on, and of public snippets that are not burnt after being read. Each
+// result carries the role of the user on it, so that collaborators can tell which they edit
+func (ss SnippetService) Search(userID string, query string) ([]snippets.SearchResult, error) {
+	results := []snippets.SearchResult{}
+	rows, err := ss.DB.Queryx(`SELECT `+snippetColumns+`, `+roleColumn+`, ts_rank(search_vector, query) AS rank,
+								ts_headline('english', COALESCE(description, '') || E'\n' || content, query, $3) AS headline
+								FROM snippet, websearch_to_tsquery('english', $1) query
+								WHERE search_vector @@ query AND `+live+`
+								AND ((visibility='public' AND NOT burn_after_read) OR `+roleExpr+`<>'')
+								ORDER BY rank DESC, id LIMIT $4`, query, nullString(userID), headlineOptions, maxSearchResults)
+	if err != nil {
+		return nil, errors.New("Error searching snippets: " + err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var row searchRow
+		err := rows.StructScan(&row)
+		if err != nil {
+			return nil, errors.New("Error searching snippets: " + err.Error())
+		}
+		results = append(results, snippets.SearchResult{
+			Snippet:    row.toSnippet(),
+			Rank:       row.Rank,
+			Highlights: highlightFragments(row.Headline),
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("Error searching snippets: " + err.Error())
+	}
+
+	return results, nil
+}
+
+// refreshSearchVector recomputes the search vector of the snippet with the given snippetID
+// from its filenames, tags, description and contents, in decreasing order of weight
+func refreshSearchVector(tx *sqlx.Tx, snippetID string) error {
+	_, err := tx.Exec(`UPDATE snippet SET search_vector =
+						setweight(to_tsvector('english', filename || ' ' || COALESCE((SELECT string_agg(filename, ' ')
+							FROM snippet_file WHERE snippet_id=snippet.id), '')), 'A') ||
+						setweight(to_tsvector('english', COALESCE((SELECT string_agg(tag.name, ' ')
+							FROM snippet_tag JOIN tag ON tag.id=snippet_tag.tag_id WHERE snippet_tag.snippet_id=snippet.id), '')), 'B') ||
+						setweight(to_tsvector('english', COALESCE(description, '')), 'C') ||
+						setweight(to_tsvector('english', left(content || ' ' || COALESCE((SELECT string_agg(content, ' ')
+							FROM snippet_file WHERE snippet_id=snippet.id), ''), $2)), 'D')
+						WHERE id=$1`, snippetID, maxIndexedContent)
+	if err != nil {
+		return errors.New("Error refreshing search vector: " + err.Error())
+	}
+	return nil
+}
+
+// highlightFragments splits a headline returned by ts_headline into its fragments, escaping
+// them as HTML before marking up their highlights with <mark> elements
+func highlightFragments(headline string) []string {
+	fragments := []string{}
+	for _, fragment := range strings.Split(headline, fragmentDelimiter) {
+		fragment = strings.TrimSpace(fragment)
+		if fragment == "" {
+			continue
+		}
+		fragment = html.EscapeString(fragment)
+		fragment = strings.Replace(fragment, highlightStart, "<mark>", -1)
+		fragment = strings.Replace(fragment, highlightStop, "</mark>", -1)
+		fragments = append(fragments, fragment)
+	}
+	return fragments
+}
@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -15,6 +18,48 @@ func Open(dbURL string) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// withTransaction runs fn within a database transaction, committing it should fn succeed
+// and rolling it back otherwise
+func withTransaction(db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return errors.New("Could not begin transaction: " + err.Error())
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.New("Could not commit transaction: " + err.Error())
+	}
+	return nil
+}
+
+// generateToken returns a random URL-safe token that is infeasible to guess
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// nullTime converts a zero time.Time into a SQL NULL, leaving any other time as is
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// nullString converts an empty string into a SQL NULL, leaving any other string as is
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // DBUrl represents the structure of a database connection string
 type DBUrl struct {
 	Protocol string
@@ -3,9 +3,11 @@ package postgres
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/chuabingquan/snippets"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // SnippetService implements the snippets.SnippetService interface
@@ -13,59 +15,387 @@ type SnippetService struct {
 	DB *sqlx.DB
 }
 
-// Snippet queries the database and returns a snippets.Snippet instance with the
-// given snippetID should it exist and belong to the user with the given userID
+// snippetColumns lists the columns selected for a snippets.Snippet, including those that
+// are derived from other tables
+const snippetColumns = `id, COALESCE(account_id::text, '') AS account_id, org_id, filename, description, visibility, language, share_token,
+						content, forked_from, expires_at, burn_after_read, deleted_at, created_at, updated_at,
+						(SELECT COUNT(*) FROM snippet_star WHERE snippet_id=snippet.id) AS star_count,
+						ARRAY(SELECT tag.name FROM snippet_tag JOIN tag ON tag.id=snippet_tag.tag_id
+							WHERE snippet_tag.snippet_id=snippet.id ORDER BY tag.name) AS tags`
+
+// live is a condition that leaves out the snippets that are in the trash and those that have
+// expired, including those that have been burnt after being read
+const live = `(snippet.deleted_at IS NULL AND (snippet.expires_at IS NULL OR snippet.expires_at>now()))`
+
+// roleExpr works out the role of the user given as $2 on a snippet, which is empty should the
+// user neither own nor collaborate on it. The snippets of an organization are owned by its
+// admins and owners and edited by the rest of its members rather than by their creator
+const roleExpr = `COALESCE(CASE WHEN snippet.org_id IS NULL THEN CASE WHEN snippet.account_id=$2 THEN 'owner' END
+						ELSE (SELECT CASE WHEN organization_member.role='member' THEN 'editor' ELSE 'owner' END FROM organization_member
+							WHERE organization_member.org_id=snippet.org_id AND organization_member.account_id=$2) END,
+						(SELECT role FROM snippet_collaborator
+							WHERE snippet_collaborator.snippet_id=snippet.id AND snippet_collaborator.account_id=$2), '')`
+
+// roleColumn selects roleExpr as the role of a snippets.Snippet
+const roleColumn = roleExpr + ` AS role`
+
+// snippetRow represents a row selected with snippetColumns, holding the columns that need
+// to be scanned into database specific types
+type snippetRow struct {
+	snippets.Snippet
+	Tags pq.StringArray `db:"tags"`
+}
+
+// toSnippet converts a snippetRow into a snippets.Snippet
+func (row snippetRow) toSnippet() snippets.Snippet {
+	snippet := row.Snippet
+	snippet.Tags = []string(row.Tags)
+	if snippet.Tags == nil {
+		snippet.Tags = []string{}
+	}
+	return snippet
+}
+
+// snippetSorts maps the keys snippets can be sorted by to the column they are ordered by
+// and the value of that column for a given snippet
+var snippetSorts = map[string]struct {
+	column string
+	value  func(s snippets.Snippet) string
+}{
+	"filename": {"filename", func(s snippets.Snippet) string { return s.Filename }},
+	"created":  {"created_at", func(s snippets.Snippet) string { return s.CreatedAt.Format(time.RFC3339Nano) }},
+	"updated":  {"updated_at", func(s snippets.Snippet) string { return s.UpdatedAt.Format(time.RFC3339Nano) }},
+}
+
+// Snippet queries the database and returns a snippets.Snippet instance with the given
+// snippetID should it exist and either belong to the user with the given userID, belong to
+// an organization the user is a member of or have the user as a collaborator, along with
+// the role of the user on it
 func (ss SnippetService) Snippet(userID string, snippetID string) (snippets.Snippet, error) {
-	var snippet snippets.Snippet
-	err := ss.DB.QueryRowx("SELECT * FROM snippet WHERE id=$1 AND account_id=$2", snippetID, userID).StructScan(&snippet)
-	if err == sql.ErrNoRows {
-		return snippet, nil
-	} else if err != nil {
-		return snippet, errors.New("Error retrieving snippet: " + err.Error())
+	return getSnippet(ss.DB, "SELECT "+snippetColumns+", "+roleColumn+" FROM snippet WHERE id=$1 AND "+live+" AND "+roleExpr+"<>''",
+		snippetID, userID)
+}
+
+// Snippets queries the database and returns a page of the snippets.Snippet given a userID
+// they associate with, narrowed down by the given filter. Snippets the user created for an
+// organization are listed with the organization instead
+func (ss SnippetService) Snippets(userID string, filter snippets.SnippetFilter, opts snippets.ListOptions) ([]snippets.Snippet, snippets.Page, error) {
+	return listSnippets(ss.DB, "account_id=$1 AND org_id IS NULL", userID, filter, opts)
+}
+
+// PublicSnippet queries the database and returns a snippets.Snippet instance with the
+// given snippetID should it exist and be public, regardless of who owns it. A snippet that
+// is burnt after being read is not burnt by being returned, see BurnSnippet
+func (ss SnippetService) PublicSnippet(snippetID string) (snippets.Snippet, error) {
+	return getSnippet(ss.DB, "SELECT "+snippetColumns+" FROM snippet WHERE id=$1 AND visibility='public' AND "+live, snippetID)
+}
+
+// PublicSnippets queries the database and returns a page of the public snippets, narrowed
+// down by the given filter and leaving out those that are burnt after being read
+func (ss SnippetService) PublicSnippets(filter snippets.SnippetFilter,
+	opts snippets.ListOptions) ([]snippets.Snippet, snippets.Page, error) {
+	return listSnippets(ss.DB, "visibility=$1 AND NOT burn_after_read", string(snippets.VisibilityPublic), filter, opts)
+}
+
+// SharedSnippet queries the database and returns a snippets.Snippet instance with the given
+// shareToken should it exist and not be private, regardless of who owns it. A snippet that
+// is burnt after being read is not burnt by being returned, see BurnSnippet
+func (ss SnippetService) SharedSnippet(shareToken string) (snippets.Snippet, error) {
+	return getSnippet(ss.DB, "SELECT "+snippetColumns+" FROM snippet WHERE share_token=$1 AND visibility<>'private' AND "+live,
+		shareToken)
+}
+
+// PurgeExpiredSnippets removes every snippet that has expired from the database and returns
+// the number of snippets removed
+func (ss SnippetService) PurgeExpiredSnippets() (int64, error) {
+	res, err := ss.DB.Exec("DELETE FROM snippet WHERE expires_at<=now()")
+	if err != nil {
+		return 0, errors.New("Error purging expired snippets: " + err.Error())
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.New("Error checking rows affected after purging expired snippets: " + err.Error())
 	}
-	return snippet, nil
+	return rows, nil
 }
 
-// Snippets queries the database and returns a slice of snippets.Snippet given
-// a userID they associate with
-func (ss SnippetService) Snippets(userID string) ([]snippets.Snippet, error) {
-	snippetSlice := []snippets.Snippet{}
-	rows, err := ss.DB.Queryx("SELECT * FROM snippet WHERE account_id=$1", userID)
+// GenerateShareToken creates a new share token for the snippet with the given snippetID,
+// replacing any token it previously had, and returns it
+func (ss SnippetService) GenerateShareToken(snippetID string) (string, error) {
+	token, err := generateToken()
 	if err != nil {
-		return nil, errors.New("Error retrieving snippets: " + err.Error())
+		return "", errors.New("Error generating share token: " + err.Error())
 	}
 
-	defer rows.Close()
+	res, err := ss.DB.Exec("UPDATE snippet SET share_token=$2 WHERE id=$1", snippetID, token)
+	if err != nil {
+		return "", errors.New("Error generating share token: " + err.Error())
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return "", errors.New("Error checking rows affected after share token generation: " + err.Error())
+	} else if rows < 1 {
+		return "", errors.New("Snippet with the given UUID does not exist")
+	}
+	return token, nil
+}
 
-	for rows.Next() {
-		var snippet snippets.Snippet
-		err := rows.StructScan(&snippet)
+// RevokeShareToken removes the share token of the snippet with the given snippetID so that
+// it can no longer be used to view the snippet
+func (ss SnippetService) RevokeShareToken(snippetID string) error {
+	_, err := ss.DB.Exec("UPDATE snippet SET share_token=NULL WHERE id=$1", snippetID)
+	if err != nil {
+		return errors.New("Error revoking share token: " + err.Error())
+	}
+	return nil
+}
+
+// CreateSnippet inserts a new snippet into the database for a given userID along with the
+// given files other than its primary one, recording its initial revision, and returns its ID
+func (ss SnippetService) CreateSnippet(s snippets.Snippet, files []snippets.SnippetFile) (string, error) {
+	var snippetID string
+	err := withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		var err error
+		snippetID, err = importSnippet(tx, s.Owner, snippets.SnippetImport{Snippet: s, Files: files})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return snippetID, nil
+}
+
+// UpdateSnippet updates an existing snippet in the database should the user with the given
+// userID be permitted to edit it, recording the change as a revision authored by the user
+func (ss SnippetService) UpdateSnippet(userID string, updatedSnippet snippets.Snippet) error {
+	return withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		role, err := snippetRole(tx, userID, updatedSnippet.ID)
 		if err != nil {
-			return nil, errors.New("Error retrieving snippets: " + err.Error())
+			return err
 		}
-		snippetSlice = append(snippetSlice, snippet)
+		if !role.CanEdit() {
+			return errors.New("User is not permitted to update the snippet")
+		}
+
+		if err = updateSnippet(tx, updatedSnippet); err != nil {
+			return err
+		}
+		return recordChange(tx, userID, updatedSnippet.ID)
+	})
+}
+
+// EditSnippet updates an existing snippet in the database and makes the given changes to its
+// files at once should the user with the given userID be permitted to edit it, recording
+// them as a single revision authored by the user. The changes are made in the order given
+func (ss SnippetService) EditSnippet(userID string, updatedSnippet snippets.Snippet, changes []snippets.SnippetFileChange) error {
+	return withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		role, err := snippetRole(tx, userID, updatedSnippet.ID)
+		if err != nil {
+			return err
+		}
+		if !role.CanEdit() {
+			return errors.New("User is not permitted to edit the snippet")
+		}
+
+		if err = updateSnippet(tx, updatedSnippet); err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if change.File == nil {
+				err = removeSnippetFile(tx, updatedSnippet.ID, change.Filename)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			file := *change.File
+			file.SnippetID = updatedSnippet.ID
+			updated, err := updateSnippetFile(tx, change.Filename, file)
+			if err != nil {
+				return err
+			}
+			if !updated {
+				if err = addSnippetFile(tx, file); err != nil {
+					return err
+				}
+			}
+		}
+		return recordChange(tx, userID, updatedSnippet.ID)
+	})
+}
+
+// DeleteSnippet moves a snippet to the trash should its given snippetID exist and the user
+// with the given userID be permitted to delete it. The snippet is only removed from the
+// database once it is purged from the trash
+func (ss SnippetService) DeleteSnippet(userID string, snippetID string) error {
+	return withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		role, err := snippetRole(tx, userID, snippetID)
+		if err != nil {
+			return err
+		}
+		if !role.CanManage() {
+			return errors.New("User is not permitted to delete the snippet")
+		}
+
+		_, err = tx.Exec("UPDATE snippet SET deleted_at=now() WHERE id=$1", snippetID)
+		if err != nil {
+			return errors.New("Error deleting snippet: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// SnippetFile queries the database and returns the file with the given filename that
+// belongs to the snippet with the given snippetID, including the snippet's primary file
+func (ss SnippetService) SnippetFile(snippetID string, filename string) (snippets.SnippetFile, error) {
+	var file snippets.SnippetFile
+	err := ss.DB.QueryRowx(`SELECT snippet_id, filename, content FROM (`+snippetFilesQuery+`) f WHERE filename=$2`,
+		snippetID, filename).StructScan(&file)
+	if err == sql.ErrNoRows {
+		return file, nil
+	} else if err != nil {
+		return file, errors.New("Error retrieving snippet file: " + err.Error())
 	}
+	return file, nil
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, errors.New("Error retrieving snippets: " + err.Error())
+// SnippetFiles queries the database and returns every file of the snippet with the given
+// snippetID, starting with the snippet's primary file followed by the rest in filename order
+func (ss SnippetService) SnippetFiles(snippetID string) ([]snippets.SnippetFile, error) {
+	return snippetFiles(ss.DB, snippetID)
+}
+
+// AddSnippetFile inserts a new file into the database for the snippet it references should
+// the user with the given userID be permitted to edit it, recording the change as a revision
+// authored by the user
+func (ss SnippetService) AddSnippetFile(userID string, f snippets.SnippetFile) error {
+	return withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		role, err := snippetRole(tx, userID, f.SnippetID)
+		if err != nil {
+			return err
+		}
+		if !role.CanEdit() {
+			return errors.New("User is not permitted to edit the snippet")
+		}
+
+		if err = addSnippetFile(tx, f); err != nil {
+			return err
+		}
+		return recordChange(tx, userID, f.SnippetID)
+	})
+}
+
+// UpdateSnippetFile updates the content of the snippet file with the given filename, renaming
+// it should updatedFile carry a different filename. The snippet's primary file is updated
+// on the snippet itself. The user with the given userID has to be permitted to edit the
+// snippet, and the change is recorded as a revision authored by the user
+func (ss SnippetService) UpdateSnippetFile(userID string, filename string, updatedFile snippets.SnippetFile) error {
+	return withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		role, err := snippetRole(tx, userID, updatedFile.SnippetID)
+		if err != nil {
+			return err
+		}
+		if !role.CanEdit() {
+			return errors.New("User is not permitted to edit the snippet")
+		}
+
+		updated, err := updateSnippetFile(tx, filename, updatedFile)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return errors.New("Snippet file with the given filename does not exist")
+		}
+		return recordChange(tx, userID, updatedFile.SnippetID)
+	})
+}
+
+// RemoveSnippetFile removes a file from the snippet with the given snippetID should the user
+// with the given userID be permitted to edit it, recording the change as a revision authored
+// by the user. A snippet's primary file cannot be removed this way as it is only removed
+// along with the snippet
+func (ss SnippetService) RemoveSnippetFile(userID string, snippetID string, filename string) error {
+	return withTransaction(ss.DB, func(tx *sqlx.Tx) error {
+		role, err := snippetRole(tx, userID, snippetID)
+		if err != nil {
+			return err
+		}
+		if !role.CanEdit() {
+			return errors.New("User is not permitted to edit the snippet")
+		}
+
+		if err = removeSnippetFile(tx, snippetID, filename); err != nil {
+			return err
+		}
+		return recordChange(tx, userID, snippetID)
+	})
+}
+
+// listSnippets queries the database and returns a page of the snippets.Snippet meeting the
+// given condition, in which $1 is the given argument, narrowed down by the given filter
+func listSnippets(q sqlx.Queryer, condition string, arg string, filter snippets.SnippetFilter,
+	opts snippets.ListOptions) ([]snippets.Snippet, snippets.Page, error) {
+	sort, ok := snippetSorts[opts.Sort]
+	if !ok {
+		return nil, snippets.Page{}, errors.New("Error retrieving snippets: unsupported sort " + opts.Sort)
 	}
 
-	return snippetSlice, nil
+	clause, args := keysetClause(sort.column, opts, []interface{}{
+		arg, pq.Array(normalizeTags(filter.Tags)), string(filter.Visibility), filter.Language, nullTime(filter.UpdatedSince)})
+	rows, err := selectSnippets(q, `SELECT `+snippetColumns+` FROM snippet WHERE `+condition+` AND `+live+`
+								AND (cardinality($2::text[])=0 OR id IN (`+taggedSnippetsQuery+`))
+								AND ($3='' OR visibility=$3)
+								AND ($4='' OR language=$4)
+								AND ($5::timestamptz IS NULL OR updated_at>=$5)`+clause, args...)
+	if err != nil {
+		return nil, snippets.Page{}, err
+	}
+
+	indexes, hasPrev, hasNext := keysetPage(len(rows), opts)
+	snippetSlice := make([]snippets.Snippet, len(indexes))
+	for i, index := range indexes {
+		snippetSlice[i] = rows[index]
+	}
+
+	var page snippets.Page
+	if len(snippetSlice) > 0 {
+		first, last := snippetSlice[0], snippetSlice[len(snippetSlice)-1]
+		if hasPrev {
+			page.Prev = &snippets.Cursor{Value: sort.value(first), ID: first.ID, Sort: opts.Sort, Backward: true}
+		}
+		if hasNext {
+			page.Next = &snippets.Cursor{Value: sort.value(last), ID: last.ID, Sort: opts.Sort}
+		}
+	}
+	return snippetSlice, page, nil
 }
 
-// CreateSnippet inserts a new snippet into the database for a given userID
-func (ss SnippetService) CreateSnippet(s snippets.Snippet) error {
-	_, err := ss.DB.NamedExec(`INSERT INTO snippet(account_id, filename, description, is_public) VALUES(:account_id, :filename, :description, :is_public)`, s)
+// insertSnippet inserts the given snippet along with its tags and returns its ID. Its
+// initial revision is left to be recorded by the caller
+func insertSnippet(tx *sqlx.Tx, s snippets.Snippet) (string, error) {
+	query, args, err := tx.BindNamed(`INSERT INTO snippet(account_id, org_id, filename, description, visibility, language, content, expires_at, burn_after_read)
+									VALUES(:account_id, :org_id, :filename, :description, :visibility, :language, :content, :expires_at, :burn_after_read)
+									RETURNING id`, s)
 	if err != nil {
-		return errors.New("Error creating snippet: " + err.Error())
+		return "", errors.New("Error creating snippet: " + err.Error())
 	}
-	return nil
+	var snippetID string
+	err = tx.QueryRowx(query, args...).Scan(&snippetID)
+	if err != nil {
+		return "", errors.New("Error creating snippet: " + err.Error())
+	}
+	if err = setSnippetTags(tx, snippetID, s.Tags); err != nil {
+		return "", err
+	}
+	return snippetID, nil
 }
 
-// UpdateSnippet updates an existing snippet in the database
-func (ss SnippetService) UpdateSnippet(updatedSnippet snippets.Snippet) error {
-	res, err := ss.DB.NamedExec(`UPDATE snippet SET account_id=:account_id, filename=:filename, description=:description,
-								is_public=:is_public WHERE id=:id`, updatedSnippet)
+// updateSnippet updates the given snippet along with its tags. The change is left to be
+// recorded by the caller
+func updateSnippet(tx *sqlx.Tx, s snippets.Snippet) error {
+	res, err := tx.NamedExec(`UPDATE snippet SET filename=:filename, description=:description,
+								visibility=:visibility, language=:language, content=:content, expires_at=:expires_at,
+								burn_after_read=:burn_after_read WHERE id=:id`, s)
 	if err != nil {
 		return errors.New("Error updating snippet: " + err.Error())
 	}
@@ -74,15 +404,156 @@ func (ss SnippetService) UpdateSnippet(updatedSnippet snippets.Snippet) error {
 	} else if rows < 1 {
 		return errors.New("Snippet with the given UUID does not exist")
 	}
+	return setSnippetTags(tx, s.ID, s.Tags)
+}
+
+// addSnippetFile inserts the given file for the snippet it references. The change is left to
+// be recorded by the caller
+func addSnippetFile(tx *sqlx.Tx, f snippets.SnippetFile) error {
+	_, err := tx.NamedExec(`INSERT INTO snippet_file(snippet_id, filename, content) VALUES(:snippet_id, :filename, :content)`, f)
+	if err != nil {
+		return errors.New("Error adding snippet file: " + err.Error())
+	}
 	return nil
 }
 
-// DeleteSnippet removes a snippet from the database should its given snippetID
-// exist and is associated with the user with the given userID
-func (ss SnippetService) DeleteSnippet(userID string, snippetID string) error {
-	_, err := ss.DB.Exec("DELETE FROM snippet WHERE id=$1 AND account_id=$2", snippetID, userID)
+// updateSnippetFile replaces the file with the given filename of the snippet the given file
+// references by it, which is the snippet itself for its primary file, and reports whether
+// there was such a file. The change is left to be recorded by the caller
+func updateSnippetFile(tx *sqlx.Tx, filename string, f snippets.SnippetFile) (bool, error) {
+	res, err := tx.Exec("UPDATE snippet SET filename=$3, content=$4 WHERE id=$1 AND filename=$2",
+		f.SnippetID, filename, f.Filename, f.Content)
+	if err != nil {
+		return false, errors.New("Error updating snippet file: " + err.Error())
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.New("Error checking rows affected after snippet file update: " + err.Error())
+	}
+
+	if rows < 1 {
+		res, err = tx.Exec("UPDATE snippet_file SET filename=$3, content=$4 WHERE snippet_id=$1 AND filename=$2",
+			f.SnippetID, filename, f.Filename, f.Content)
+		if err != nil {
+			return false, errors.New("Error updating snippet file: " + err.Error())
+		}
+		rows, err = res.RowsAffected()
+		if err != nil {
+			return false, errors.New("Error checking rows affected after snippet file update: " + err.Error())
+		}
+	}
+	return rows > 0, nil
+}
+
+// removeSnippetFile removes the file with the given filename from the snippet with the given
+// snippetID. The change is left to be recorded by the caller
+func removeSnippetFile(tx *sqlx.Tx, snippetID string, filename string) error {
+	_, err := tx.Exec("DELETE FROM snippet_file WHERE snippet_id=$1 AND filename=$2", snippetID, filename)
 	if err != nil {
-		return errors.New("Error deleting snippet: " + err.Error())
+		return errors.New("Error removing snippet file: " + err.Error())
 	}
 	return nil
 }
+
+// BurnSnippet expires the snippet with the given snippetID, which is burnt after being read,
+// as its content has just been read by someone other than its owner. Only one of the readers
+// racing to read such a snippet burns it, false is returned to the rest as though it has
+// already expired
+func (ss SnippetService) BurnSnippet(snippetID string) (bool, error) {
+	res, err := ss.DB.Exec("UPDATE snippet SET expires_at=now() WHERE id=$1 AND burn_after_read AND "+live, snippetID)
+	if err != nil {
+		return false, errors.New("Error burning snippet after read: " + err.Error())
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.New("Error checking rows affected after burning snippet: " + err.Error())
+	}
+	return rows > 0, nil
+}
+
+// recordChange brings when the snippet with the given snippetID was last updated and
+// everything derived from it up to date after the user with the given userID has changed it
+func recordChange(tx *sqlx.Tx, userID string, snippetID string) error {
+	_, err := tx.Exec("UPDATE snippet SET updated_at=now() WHERE id=$1", snippetID)
+	if err != nil {
+		return errors.New("Error updating snippet timestamp: " + err.Error())
+	}
+	if err := refreshSearchVector(tx, snippetID); err != nil {
+		return err
+	}
+	return createRevision(tx, userID, snippetID)
+}
+
+// snippetFiles returns every file of the snippet with the given snippetID, starting with
+// the snippet's primary file followed by the rest in filename order
+func snippetFiles(q sqlx.Queryer, snippetID string) ([]snippets.SnippetFile, error) {
+	files := []snippets.SnippetFile{}
+	rows, err := q.Queryx(`SELECT snippet_id, filename, content FROM (`+snippetFilesQuery+`) f
+							ORDER BY is_primary DESC, filename`, snippetID)
+	if err != nil {
+		return nil, errors.New("Error retrieving snippet files: " + err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var file snippets.SnippetFile
+		err := rows.StructScan(&file)
+		if err != nil {
+			return nil, errors.New("Error retrieving snippet files: " + err.Error())
+		}
+		files = append(files, file)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("Error retrieving snippet files: " + err.Error())
+	}
+
+	return files, nil
+}
+
+// getSnippet runs a query that selects snippetColumns and returns the resulting row as a
+// snippets.Snippet, or an empty snippets.Snippet should there be none
+func getSnippet(q sqlx.Queryer, query string, args ...interface{}) (snippets.Snippet, error) {
+	var row snippetRow
+	err := q.QueryRowx(query, args...).StructScan(&row)
+	if err == sql.ErrNoRows {
+		return snippets.Snippet{}, nil
+	} else if err != nil {
+		return snippets.Snippet{}, errors.New("Error retrieving snippet: " + err.Error())
+	}
+	return row.toSnippet(), nil
+}
+
+// selectSnippets runs a query that selects snippetColumns and returns the resulting rows
+// as a slice of snippets.Snippet
+func selectSnippets(q sqlx.Queryer, query string, args ...interface{}) ([]snippets.Snippet, error) {
+	snippetSlice := []snippets.Snippet{}
+	rows, err := q.Queryx(query, args...)
+	if err != nil {
+		return nil, errors.New("Error retrieving snippets: " + err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var row snippetRow
+		err := rows.StructScan(&row)
+		if err != nil {
+			return nil, errors.New("Error retrieving snippets: " + err.Error())
+		}
+		snippetSlice = append(snippetSlice, row.toSnippet())
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("Error retrieving snippets: " + err.Error())
+	}
+
+	return snippetSlice, nil
+}
+
+// snippetFilesQuery selects every file of the snippet given by $1, flagging the primary file
+// that is stored on the snippet itself
+const snippetFilesQuery = `SELECT id AS snippet_id, filename, content, TRUE AS is_primary FROM snippet WHERE id=$1
+							UNION ALL
+							SELECT snippet_id, filename, content, FALSE AS is_primary FROM snippet_file WHERE snippet_id=$1`
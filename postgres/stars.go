@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/chuabingquan/snippets"
+)
+
+// StarSnippet stars the snippet with the given snippetID on behalf of the user with the
+// given userID. Starring an already starred snippet has no effect
+func (ss SnippetService) StarSnippet(userID string, snippetID string) error {
+	_, err := ss.DB.Exec(`INSERT INTO snippet_star(account_id, snippet_id) VALUES($1, $2)
+						ON CONFLICT (account_id, snippet_id) DO NOTHING`, userID, snippetID)
+	if err != nil {
+		return errors.New("Error starring snippet: " + err.Error())
+	}
+	return nil
+}
+
+// UnstarSnippet removes the star the user with the given userID gave the snippet with the
+// given snippetID
+func (ss SnippetService) UnstarSnippet(userID string, snippetID string) error {
+	_, err := ss.DB.Exec("DELETE FROM snippet_star WHERE account_id=$1 AND snippet_id=$2", userID, snippetID)
+	if err != nil {
+		return errors.New("Error unstarring snippet: " + err.Error())
+	}
+	return nil
+}
+
+// StarredSnippet queries the database and returns a snippets.Snippet instance with the given
+// snippetID should the user with the given userID have starred it and should it remain
+// visible to them, as it would be listed by StarredSnippets
+func (ss SnippetService) StarredSnippet(userID string, snippetID string) (snippets.Snippet, error) {
+	return getSnippet(ss.DB, `SELECT `+snippetColumns+`, `+roleColumn+` FROM snippet
+							WHERE id=$1 AND id IN (SELECT snippet_id FROM snippet_star WHERE account_id=$2)
+							AND `+live+` AND ((visibility<>'private' AND NOT burn_after_read) OR `+roleExpr+`<>'')`, snippetID, userID)
+}
+
+// StarredSnippets queries the database and returns the snippets starred by the user with
+// the given userID that remain visible to them, being public or having them hold a role on
+// them, most recently starred first. Each snippet carries the role of the user on it
+func (ss SnippetService) StarredSnippets(userID string) ([]snippets.Snippet, error) {
+	return selectSnippets(ss.DB, `SELECT `+snippetColumns+`, `+roleColumn+` FROM snippet
+								WHERE id IN (SELECT snippet_id FROM snippet_star WHERE account_id=$1)
+								AND `+live+` AND ((visibility<>'private' AND NOT burn_after_read) OR `+roleExpr+`<>'')
+								ORDER BY (SELECT created_at FROM snippet_star
+										WHERE snippet_star.snippet_id=snippet.id AND snippet_star.account_id=$1) DESC`, userID, userID)
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/chuabingquan/snippets"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// taggedSnippetsQuery selects the IDs of the snippets that have every tag in the text array $2
+const taggedSnippetsQuery = `SELECT snippet_tag.snippet_id FROM snippet_tag JOIN tag ON tag.id=snippet_tag.tag_id
+							WHERE tag.name=ANY($2::text[]) GROUP BY snippet_tag.snippet_id
+							HAVING COUNT(*)=cardinality($2::text[])`
+
+// Tags queries the database and returns the tags used on the snippets of the user with the
+// given userID along with the number of snippets each is used on, most used first
+func (ss SnippetService) Tags(userID string) ([]snippets.TagCount, error) {
+	tags := []snippets.TagCount{}
+	rows, err := ss.DB.Queryx(`SELECT tag.name, COUNT(*) AS count FROM tag
+								JOIN snippet_tag ON snippet_tag.tag_id=tag.id
+								JOIN snippet ON snippet.id=snippet_tag.snippet_id
+								WHERE snippet.account_id=$1 AND snippet.org_id IS NULL AND `+live+` GROUP BY tag.name ORDER BY count DESC, tag.name`, userID)
+	if err != nil {
+		return nil, errors.New("Error retrieving tags: " + err.Error())
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag snippets.TagCount
+		err := rows.StructScan(&tag)
+		if err != nil {
+			return nil, errors.New("Error retrieving tags: " + err.Error())
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.New("Error retrieving tags: " + err.Error())
+	}
+
+	return tags, nil
+}
+
+// setSnippetTags replaces the tags of the snippet with the given snippetID with the given
+// tags, creating any tag that does not exist yet
+func setSnippetTags(tx *sqlx.Tx, snippetID string, tags []string) error {
+	tags = normalizeTags(tags)
+
+	_, err := tx.Exec("DELETE FROM snippet_tag WHERE snippet_id=$1", snippetID)
+	if err != nil {
+		return errors.New("Error setting snippet tags: " + err.Error())
+	}
+	if len(tags) < 1 {
+		return nil
+	}
+
+	_, err = tx.Exec("INSERT INTO tag(name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING", pq.Array(tags))
+	if err != nil {
+		return errors.New("Error setting snippet tags: " + err.Error())
+	}
+	_, err = tx.Exec("INSERT INTO snippet_tag(snippet_id, tag_id) SELECT $1, id FROM tag WHERE name=ANY($2::text[])",
+		snippetID, pq.Array(tags))
+	if err != nil {
+		return errors.New("Error setting snippet tags: " + err.Error())
+	}
+	return nil
+}
+
+// normalizeTags lowercases the given tags and removes surrounding whitespace and duplicates
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
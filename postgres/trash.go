@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chuabingquan/snippets"
+)
+
+// trashed is a condition that leaves out every snippet but those in the trash, which excludes
+// the snippets that expired before they could be restored and those of deleted organizations,
+// which are only kept until they are purged along with their organization
+const trashed = `(snippet.deleted_at IS NOT NULL AND (snippet.expires_at IS NULL OR snippet.expires_at>now())
+					AND (snippet.org_id IS NULL OR snippet.org_id IN (SELECT id FROM organization WHERE deleted_at IS NULL)))`
+
+// TrashedSnippet queries the database and returns a snippets.Snippet instance with the given
+// snippetID should it be in the trash and the user with the given userID be permitted to
+// restore it
+func (ss SnippetService) TrashedSnippet(userID string, snippetID string) (snippets.Snippet, error) {
+	return getSnippet(ss.DB, "SELECT "+snippetColumns+", "+roleColumn+" FROM snippet WHERE id=$1 AND "+trashed+" AND "+roleExpr+"='owner'",
+		snippetID, userID)
+}
+
+// TrashedSnippets queries the database and returns every snippet in the trash that the user
+// with the given userID is permitted to restore, most recently deleted first
+func (ss SnippetService) TrashedSnippets(userID string) ([]snippets.Snippet, error) {
+	return selectSnippets(ss.DB, `SELECT `+snippetColumns+`, `+roleColumn+` FROM snippet
+								WHERE (org_id IS NOT NULL OR account_id=$1) AND `+trashed+` AND `+roleExpr+`='owner'
+								ORDER BY deleted_at DESC, id`, userID, userID)
+}
+
+// RestoreSnippet moves the snippet with the given snippetID out of the trash
+func (ss SnippetService) RestoreSnippet(snippetID string) error {
+	res, err := ss.DB.Exec("UPDATE snippet SET deleted_at=NULL WHERE id=$1 AND deleted_at IS NOT NULL", snippetID)
+	if err != nil {
+		return errors.New("Error restoring snippet: " + err.Error())
+	}
+	if rows, err := res.RowsAffected(); err != nil {
+		return errors.New("Error checking rows affected after snippet restoration: " + err.Error())
+	} else if rows < 1 {
+		return errors.New("Snippet with the given UUID is not in the trash")
+	}
+	return nil
+}
+
+// PurgeDeletedSnippets removes every snippet moved to the trash before the given time from
+// the database and returns the number of snippets removed
+func (ss SnippetService) PurgeDeletedSnippets(before time.Time) (int64, error) {
+	res, err := ss.DB.Exec("DELETE FROM snippet WHERE deleted_at<=$1", before)
+	if err != nil {
+		return 0, errors.New("Error purging deleted snippets: " + err.Error())
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.New("Error checking rows affected after purging deleted snippets: " + err.Error())
+	}
+	return rows, nil
+}
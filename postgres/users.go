@@ -3,6 +3,7 @@ package postgres
 import (
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/chuabingquan/snippets"
 	"github.com/jmoiron/sqlx"
@@ -14,11 +15,22 @@ type UserService struct {
 	HashUtilities snippets.HashUtilities
 }
 
+// userSorts maps the keys users can be sorted by to the column they are ordered by and the
+// value of that column for a given user
+var userSorts = map[string]struct {
+	column string
+	value  func(u snippets.User) string
+}{
+	"username": {"username", func(u snippets.User) string { return u.Username }},
+	"created":  {"created_at", func(u snippets.User) string { return u.CreatedAt.Format(time.RFC3339Nano) }},
+	"updated":  {"updated_at", func(u snippets.User) string { return u.UpdatedAt.Format(time.RFC3339Nano) }},
+}
+
 // User returns a snippets.User after querying from the database given a userID,
-// else, an error occurs such as when the user isn't found
+// else, an error occurs such as when the user isn't found. Deleted users are not found
 func (us UserService) User(userID string) (snippets.User, error) {
 	var user snippets.User
-	err := us.DB.QueryRowx("SELECT * FROM account WHERE id=$1", userID).StructScan(&user)
+	err := us.DB.QueryRowx("SELECT * FROM account WHERE id=$1 AND deleted_at IS NULL", userID).StructScan(&user)
 	if err == sql.ErrNoRows {
 		return user, nil
 	} else if err != nil {
@@ -31,7 +43,7 @@ func (us UserService) User(userID string) (snippets.User, error) {
 // of a userID as an argument
 func (us UserService) UserByUsername(username string) (snippets.User, error) {
 	var user snippets.User
-	err := us.DB.QueryRowx("SELECT * FROM account WHERE username=$1", username).StructScan(&user)
+	err := us.DB.QueryRowx("SELECT * FROM account WHERE username=$1 AND deleted_at IS NULL", username).StructScan(&user)
 	if err == sql.ErrNoRows {
 		return user, nil
 	} else if err != nil {
@@ -40,30 +52,54 @@ func (us UserService) UserByUsername(username string) (snippets.User, error) {
 	return user, nil
 }
 
-// Users returns all users from the database in the form of a snippets.User slice
-func (us UserService) Users() ([]snippets.User, error) {
-	users := []snippets.User{}
-	rows, err := us.DB.Queryx("SELECT * FROM account")
+// Users returns a page of the users from the database in the form of a snippets.User slice,
+// narrowed down by the given filter
+func (us UserService) Users(filter snippets.UserFilter, opts snippets.ListOptions) ([]snippets.User, snippets.Page, error) {
+	sort, ok := userSorts[opts.Sort]
+	if !ok {
+		return nil, snippets.Page{}, errors.New("Error retrieving users: unsupported sort " + opts.Sort)
+	}
+
+	clause, args := keysetClause(sort.column, opts, []interface{}{filter.Username, nullTime(filter.UpdatedSince)})
+	rows, err := us.DB.Queryx(`SELECT * FROM account WHERE deleted_at IS NULL AND ($1='' OR starts_with(username, $1))
+								AND ($2::timestamptz IS NULL OR updated_at>=$2)`+clause, args...)
 	if err != nil {
-		return nil, errors.New("Error retrieving users: " + err.Error())
+		return nil, snippets.Page{}, errors.New("Error retrieving users: " + err.Error())
 	}
 
 	defer rows.Close()
 
+	selected := []snippets.User{}
 	for rows.Next() {
 		var user snippets.User
 		err := rows.StructScan(&user)
 		if err != nil {
-			return nil, errors.New("Error retrieving users: " + err.Error())
+			return nil, snippets.Page{}, errors.New("Error retrieving users: " + err.Error())
 		}
-		users = append(users, user)
+		selected = append(selected, user)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, errors.New("Error retrieving users: " + err.Error())
+		return nil, snippets.Page{}, errors.New("Error retrieving users: " + err.Error())
+	}
+
+	indexes, hasPrev, hasNext := keysetPage(len(selected), opts)
+	users := make([]snippets.User, len(indexes))
+	for i, index := range indexes {
+		users[i] = selected[index]
 	}
 
-	return users, nil
+	var page snippets.Page
+	if len(users) > 0 {
+		first, last := users[0], users[len(users)-1]
+		if hasPrev {
+			page.Prev = &snippets.Cursor{Value: sort.value(first), ID: first.ID, Sort: opts.Sort, Backward: true}
+		}
+		if hasNext {
+			page.Next = &snippets.Cursor{Value: sort.value(last), ID: last.ID, Sort: opts.Sort}
+		}
+	}
+	return users, page, nil
 }
 
 // CreateUser inserts the data from a given snippets.User instance into the database
@@ -93,7 +129,7 @@ func (us UserService) UpdateUser(updatedUser snippets.User) error {
 	}
 
 	res, err := us.DB.NamedExec(`UPDATE account SET email=:email, username=:username, password_hash=:password_hash, 
-					first_name=:first_name, last_name=:last_name WHERE id=:id`, updatedUser)
+					first_name=:first_name, last_name=:last_name, updated_at=now() WHERE id=:id AND deleted_at IS NULL`, updatedUser)
 	if err != nil {
 		return errors.New("Error updating user: " + err.Error())
 	}
@@ -105,11 +141,51 @@ func (us UserService) UpdateUser(updatedUser snippets.User) error {
 	return nil
 }
 
-// DeleteUser removes a user with a matching userID (given) from the database
+// DeleteUser marks a user with a matching userID (given) as deleted along with the snippets
+// they own personally, which are moved to the trash. The user is removed from the database
+// once they are purged
 func (us UserService) DeleteUser(userID string) error {
-	_, err := us.DB.Exec("DELETE FROM account WHERE id=$1", userID)
+	return withTransaction(us.DB, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("UPDATE account SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", userID)
+		if err != nil {
+			return errors.New("Error deleting user: " + err.Error())
+		}
+		_, err = tx.Exec("UPDATE snippet SET deleted_at=now() WHERE account_id=$1 AND org_id IS NULL AND deleted_at IS NULL", userID)
+		if err != nil {
+			return errors.New("Error deleting user snippets: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// PurgeDeletedUsers removes every user deleted before the given time from the database along
+// with the snippets they own personally, and returns the number of users removed. The
+// snippets they created for an organization remain with the organization, as do their
+// comments that have been replied to, without their body
+func (us UserService) PurgeDeletedUsers(before time.Time) (int64, error) {
+	var purged int64
+	err := withTransaction(us.DB, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(`DELETE FROM snippet WHERE org_id IS NULL
+							AND account_id IN (SELECT id FROM account WHERE deleted_at<=$1)`, before)
+		if err != nil {
+			return errors.New("Error purging deleted user snippets: " + err.Error())
+		}
+		err = removeComments(tx, "author_id IN (SELECT id FROM account WHERE deleted_at<=$1)", before)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec("DELETE FROM account WHERE deleted_at<=$1", before)
+		if err != nil {
+			return errors.New("Error purging deleted users: " + err.Error())
+		}
+		purged, err = res.RowsAffected()
+		if err != nil {
+			return errors.New("Error checking rows affected after purging deleted users: " + err.Error())
+		}
+		return nil
+	})
 	if err != nil {
-		return errors.New("Error deleting user: " + err.Error())
+		return 0, err
 	}
-	return nil
+	return purged, nil
 }
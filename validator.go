@@ -4,6 +4,7 @@ import (
 	"errors"
 	"regexp"
 	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
@@ -30,11 +31,110 @@ func (u User) Validate() error {
 	)
 }
 
+// Validate checks if the values of a Snippet struct has met a set of requirements
+// and returns an error should it fail any of it
+func (s Snippet) Validate() error {
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.ID, validation.Skip, is.UUIDv4),
+		validation.Field(&s.Filename, filenameRules...),
+		validation.Field(&s.Description, validation.Length(0, 255)),
+		validation.Field(&s.Visibility, validation.Required,
+			validation.In(VisibilityPrivate, VisibilityUnlisted, VisibilityPublic)),
+		validation.Field(&s.Language, validation.Required, validation.By(checkLanguage)),
+		validation.Field(&s.Tags, validation.Length(0, 20), validation.By(checkTags)),
+		validation.Field(&s.ExpiresAt, validation.By(checkFutureTime)),
+	)
+}
+
+// Validate checks if the values of a Collection struct has met a set of requirements
+// and returns an error should it fail any of it
+func (c Collection) Validate() error {
+	c.Name = strings.Trim(c.Name, " ")
+
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.ID, validation.Skip, is.UUIDv4),
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&c.Description, validation.Length(0, 255)),
+		validation.Field(&c.Visibility, validation.Required,
+			validation.In(VisibilityPrivate, VisibilityUnlisted, VisibilityPublic)),
+		validation.Field(&c.SnippetIDs, validation.Length(0, 100), validation.By(checkSnippetIDs)),
+	)
+}
+
+// Validate checks if the values of a SnippetFile struct has met a set of requirements
+// and returns an error should it fail any of it
+func (f SnippetFile) Validate() error {
+	return validation.ValidateStruct(&f,
+		validation.Field(&f.SnippetID, validation.Skip, is.UUIDv4),
+		validation.Field(&f.Filename, filenameRules...),
+	)
+}
+
+// Validate checks if the values of an Organization struct has met a set of requirements
+// and returns an error should it fail any of it
+func (o Organization) Validate() error {
+	o.DisplayName = strings.Trim(o.DisplayName, " ")
+
+	return validation.ValidateStruct(&o,
+		validation.Field(&o.ID, validation.Skip, is.UUIDv4),
+		validation.Field(&o.Name, validation.Required, validation.Length(2, 25), validation.By(checkOrgName)),
+		validation.Field(&o.DisplayName, validation.Length(0, 100)),
+	)
+}
+
+// Validate checks if the values of a Membership struct has met a set of requirements
+// and returns an error should it fail any of it
+func (m Membership) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.OrgID, validation.Skip, is.UUIDv4),
+		validation.Field(&m.UserID, validation.Required, is.UUIDv4),
+		validation.Field(&m.Role, validation.Required, validation.In(OrgRoleMember, OrgRoleAdmin, OrgRoleOwner)),
+	)
+}
+
+// Validate checks if the values of a Collaborator struct has met a set of requirements
+// and returns an error should it fail any of it
+func (c Collaborator) Validate() error {
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.SnippetID, validation.Skip, is.UUIDv4),
+		validation.Field(&c.UserID, validation.Required, is.UUIDv4),
+		validation.Field(&c.Role, validation.Required, validation.In(RoleViewer, RoleEditor)),
+	)
+}
+
+// Validate checks if the values of a Comment struct has met a set of requirements
+// and returns an error should it fail any of it. The revision, filename and lines of
+// a comment are either all given to anchor it or not given at all
+func (c Comment) Validate() error {
+	c.Body = strings.Trim(c.Body, " ")
+
+	var anchorRules []validation.Rule
+	if c.IsAnchored() {
+		anchorRules = append(anchorRules, validation.Required)
+	}
+	return validation.ValidateStruct(&c,
+		validation.Field(&c.ID, validation.Skip, is.UUIDv4),
+		validation.Field(&c.ParentID, is.UUIDv4),
+		validation.Field(&c.Body, validation.Required, validation.Length(1, 10000)),
+		validation.Field(&c.RevisionID, append(anchorRules, is.UUIDv4)...),
+		validation.Field(&c.Filename, append(anchorRules, validation.Length(1, 255))...),
+		validation.Field(&c.StartLine, append(anchorRules, validation.Min(1))...),
+		validation.Field(&c.EndLine, append(anchorRules, validation.By(func(value interface{}) error {
+			if c.StartLine != nil && c.EndLine != nil && *c.EndLine < *c.StartLine {
+				return errors.New("must not be before the start line")
+			}
+			return nil
+		}))...),
+	)
+}
+
 // Regex based custom validation rules that implements the validation.Rule interface
 var checkLowercasePresent = createRegexValidator(`(?:.*[a-z].*)`, "at least 1 lowercase character is required")
 var checkUppercasePresent = createRegexValidator(`(?:.*[A-Z].*)`, "at least 1 uppercase character is required")
 var checkNumberPresent = createRegexValidator(`(?:.*[0-9].*)`, "at least 1 number is required")
 var checkSpecialCharPresent = createRegexValidator(`(?:.*[!@#$%^&*].*)`, "at least 1 special character is required")
+var checkNoPathSeparator = createRegexValidator(`^[^/\\]*$`, "path separators are not allowed")
+var checkOrgName = createRegexValidator(`^[a-z0-9]+(?:-[a-z0-9]+)*$`, "only lowercase letters, numbers and single hyphens between them are allowed")
 
 // A collection of rules for password input validation
 var passwordRules = []validation.Rule{
@@ -46,6 +146,77 @@ var passwordRules = []validation.Rule{
 	validation.By(checkSpecialCharPresent),
 }
 
+// A collection of rules for the name of a file in a snippet
+var filenameRules = []validation.Rule{
+	validation.Required,
+	validation.Length(1, 255),
+	validation.By(checkNoPathSeparator),
+}
+
+// tagPattern is the pattern every tag of a snippet has to match
+const tagPattern = `^[A-Za-z0-9][A-Za-z0-9+#._-]{0,49}$`
+
+// checkTags is a custom validation rule that implements the validation.Rule interface to
+// check that every tag in a list of tags matches tagPattern
+func checkTags(value interface{}) error {
+	tags, ok := value.([]string)
+	if !ok {
+		return errors.New("only a list of strings is allowed")
+	}
+	for _, tag := range tags {
+		if !matchStringToPattern(tagPattern, tag) {
+			return errors.New("tags must be 1 to 50 letters, numbers or any of +#._- and start with a letter or number")
+		}
+	}
+	return nil
+}
+
+// checkSnippetIDs is a custom validation rule that implements the validation.Rule interface
+// to check that a list holds the UUIDs of distinct snippets
+func checkSnippetIDs(value interface{}) error {
+	ids, ok := value.([]string)
+	if !ok {
+		return errors.New("only a list of strings is allowed")
+	}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if is.UUIDv4.Validate(id) != nil {
+			return errors.New("snippet IDs must be valid UUIDs")
+		}
+		if seen[strings.ToLower(id)] {
+			return errors.New("snippet IDs must not be repeated")
+		}
+		seen[strings.ToLower(id)] = true
+	}
+	return nil
+}
+
+// checkLanguage is a custom validation rule that implements the validation.Rule interface to
+// check that a language is in the language registry
+func checkLanguage(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("only string is allowed")
+	}
+	if !IsKnownLanguage(s) {
+		return errors.New("must be a supported language")
+	}
+	return nil
+}
+
+// checkFutureTime is a custom validation rule that implements the validation.Rule interface to
+// check that an optional time is in the future should it be given
+func checkFutureTime(value interface{}) error {
+	t, ok := value.(*time.Time)
+	if !ok {
+		return errors.New("only a time is allowed")
+	}
+	if t != nil && !t.After(time.Now()) {
+		return errors.New("must be in the future")
+	}
+	return nil
+}
+
 // createRegexValidator generates a regex validator that implements the validation.Rule interface
 func createRegexValidator(pattern string, err string) func(interface{}) error {
 	return func(value interface{}) error {